@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,10 +15,16 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/volkowlad/week4/internal/api"
+	"github.com/volkowlad/week4/internal/api/mw"
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/blob"
+	"github.com/volkowlad/week4/internal/certs"
 	"github.com/volkowlad/week4/internal/config"
 	custumLog "github.com/volkowlad/week4/internal/logger"
 	"github.com/volkowlad/week4/internal/repos"
 	"github.com/volkowlad/week4/internal/service"
+	"github.com/volkowlad/week4/internal/worker"
+	"github.com/volkowlad/week4/internal/workflow"
 )
 
 func main() {
@@ -30,6 +38,10 @@ func main() {
 		log.Fatal(errors.Wrap(err, "failed to load configuration"))
 	}
 
+	if err := cfg.RateLimit.Validate(); err != nil {
+		log.Fatal(errors.Wrap(err, "invalid rate limit configuration"))
+	}
+
 	// Инициализация логгера
 	logger, err := custumLog.NewLogger(cfg.LogLevel)
 	if err != nil {
@@ -41,35 +53,93 @@ func main() {
 
 	ctx := context.Background()
 
-	var serviceInstance service.Service
+	// Статусы задач и переходы между ними, общие для обоих хранилищ
+	wf, err := workflow.New(cfg.Workflow)
+	if err != nil {
+		logger.Fatal(errors.Wrap(err, "error initializing workflow"))
+	}
+
+	var repository repos.Repository
 
 	switch *storageType {
 	case "postgres":
-		repository, err := repos.NewPostgres(ctx, cfg.Postgres)
+		repository, err = repos.NewPostgres(ctx, cfg.Postgres, wf)
 		if err != nil {
 			logger.Fatal(errors.Wrap(err, "error initializing postgres"))
 		}
 
-		serviceInstance = service.NewService(repository, logger)
-
 		logger.Infof("db - %v", *storageType)
 	case "memory":
-		repository := repos.NewMemory()
-
-		serviceInstance = service.NewService(repository, logger)
+		repository = repos.NewMemory(wf)
 
 		logger.Infof("db - %v", *storageType)
 	default:
-		logger.Fatal(errors.Wrap(err, "unknown storage type"))
+		logger.Fatal(errors.Errorf("unknown storage type %q", *storageType))
+	}
+
+	// Хранилище содержимого вложений
+	files, err := blob.New(ctx, cfg.Files)
+	if err != nil {
+		logger.Fatal(errors.Wrap(err, "error initializing attachments store"))
+	}
+
+	serviceInstance := service.NewService(repository, files, cfg.Files, wf, logger)
+
+	// Фоновые обработчики
+	if cfg.Overdue.Enabled {
+		go worker.NewOverdueSweeper(repository, cfg.Overdue.Interval, logger).Run(ctx)
+	}
+
+	if cfg.Archive.Enabled {
+		go worker.NewArchiver(repository, cfg.Archive.After, cfg.Archive.Interval, logger).Run(ctx)
+	}
+
+	if cfg.Trash.PurgeEnabled {
+		go worker.NewTrashPurger(repository, files, cfg.Trash.Retention, cfg.Trash.PurgeInterval, logger).Run(ctx)
+	}
+
+	// Проверка JWT от шлюза (если настроена)
+	jwtVerifier, err := auth.NewJWTVerifier(cfg.JWT)
+	if err != nil {
+		logger.Fatal(errors.Wrap(err, "error initializing jwt verifier"))
 	}
 
 	// Инициализация API
-	app := api.NewRouters(&api.Routers{Service: serviceInstance}, cfg.Rest.Token)
+	// Тело запроса с вложением больше самого файла на заголовки multipart
+	routers := &api.Routers{
+		Service:   serviceInstance,
+		BodyLimit: int(cfg.Files.MaxSize) + 1<<20,
+	}
+
+	app := api.NewRouters(routers, mw.AuthConfig{
+		Tokens:  cfg.Rest.Tokens,
+		JWT:     jwtVerifier,
+		APIKeys: repository,
+
+		ClientCertScopes: cfg.Rest.TLSClientScopes,
+	}, cfg.RateLimit)
+
+	ln, err := net.Listen("tcp", ":"+cfg.Rest.ListenAddress)
+	if err != nil {
+		logger.Fatal(errors.Wrap(err, "failed to listen"))
+	}
+
+	// TLS с перезагрузкой сертификатов по SIGHUP и при изменении файлов
+	if cfg.Rest.TLSEnabled() {
+		reloader, err := certs.NewReloader(cfg.Rest, logger)
+		if err != nil {
+			logger.Fatal(errors.Wrap(err, "error initializing tls"))
+		}
+
+		go reloader.Watch(ctx, cfg.Rest.TLSReloadInterval)
+
+		ln = tls.NewListener(ln, reloader.TLSConfig())
+	}
 
 	// Запуск HTTP-сервера в отдельной горутине
 	go func() {
-		logger.Infof("Starting server on %s", cfg.Rest.ListenAddress)
-		if err := app.Listen(":" + cfg.Rest.ListenAddress); err != nil {
+		logger.Infof("Starting server on %s (tls: %v)", cfg.Rest.ListenAddress, cfg.Rest.TLSEnabled())
+		if err := app.Listener(ln); err != nil {
 			logger.Fatal(errors.Wrap(err, "failed to start server"))
 		}
 	}()
@@ -80,4 +150,9 @@ func main() {
 	<-signalChan
 
 	logger.Info("Shutting down gracefully...")
+
+	// Дожидаемся завершения запросов, которые уже выполняются
+	if err := app.ShutdownWithTimeout(cfg.Rest.WriteTimeout); err != nil {
+		logger.Error(errors.Wrap(err, "failed to shutdown server"))
+	}
 }
@@ -5,16 +5,19 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 
 	"github.com/volkowlad/week4/internal/api/mw"
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/config"
 	"github.com/volkowlad/week4/internal/service"
 )
 
 type Routers struct {
-	Service service.Service
+	Service   service.Service
+	BodyLimit int // максимальный размер тела запроса в байтах, 0 - по умолчанию Fiber (4 МБ)
 }
 
 // NewRouters - конструктор для настройки API
-func NewRouters(r *Routers, token string) *fiber.App {
-	app := fiber.New()
+func NewRouters(r *Routers, authCfg mw.AuthConfig, limitCfg config.RateLimit) *fiber.App {
+	app := fiber.New(fiber.Config{BodyLimit: r.BodyLimit})
 
 	// Настройка CORS (разрешенные методы, заголовки, авторизация)
 	app.Use(cors.New(cors.Config{
@@ -25,16 +28,127 @@ func NewRouters(r *Routers, token string) *fiber.App {
 		MaxAge:           300,
 	}))
 
-	// Группа маршрутов с авторизацией
-	apiGroup := app.Group("/v1", mw.Authorization(token))
+	// Группа маршрутов с авторизацией и ограничением частоты запросов: по IP до проверки токена
+	// (подбор токенов тоже ограничен) и по проверенному вызывающему после неё
+	apiGroup := app.Group("/v1", mw.RateLimitByIP(limitCfg), mw.Authorization(authCfg), mw.RateLimit(limitCfg))
+
+	read := mw.RequireScope(auth.ScopeTasksRead)
+	write := mw.RequireScope(auth.ScopeTasksWrite)
+	admin := mw.RequireScope(auth.ScopeTasksAdmin)
 
 	// Роут для создания задачи
 	{
-		apiGroup.Post("/create_task", r.Service.CreateTask)
-		apiGroup.Get("/task/:id", r.Service.GetTask)
-		apiGroup.Get("/tasks", r.Service.GetAllTasks)
-		apiGroup.Delete("/delete/:id", r.Service.DeleteTask)
-		apiGroup.Put("/update/:id", r.Service.UpdateTask)
+		apiGroup.Post("/create_task", write, r.Service.CreateTask)
+		apiGroup.Get("/task/:id", read, r.Service.GetTask)
+		apiGroup.Get("/task/:id/children", read, r.Service.GetChildren)
+		apiGroup.Post("/task/:id/assignees", write, r.Service.AssignTask)
+		apiGroup.Delete("/task/:id/assignees/:assignee", write, r.Service.UnassignTask)
+		apiGroup.Get("/tasks", read, r.Service.GetAllTasks)
+		apiGroup.Delete("/delete/:id", write, r.Service.DeleteTask)
+		apiGroup.Put("/update/:id", write, r.Service.UpdateTask)
+		apiGroup.Get("/tags", read, r.Service.GetTags)
+	}
+
+	// Проекты
+	{
+		apiGroup.Post("/projects", write, r.Service.CreateProject)
+		apiGroup.Get("/projects", read, r.Service.ListProjects)
+		apiGroup.Get("/projects/:id", read, r.Service.GetProject)
+		apiGroup.Put("/projects/:id", write, r.Service.UpdateProject)
+		apiGroup.Delete("/projects/:id", write, r.Service.DeleteProject)
+		apiGroup.Get("/projects/:id/tasks", read, r.Service.GetProjectTasks)
+	}
+
+	// Пользовательские поля задач проекта
+	{
+		apiGroup.Post("/projects/:id/fields", admin, r.Service.CreateField)
+		apiGroup.Get("/projects/:id/fields", read, r.Service.ListFields)
+		apiGroup.Put("/projects/:id/fields/:key", admin, r.Service.UpdateField)
+		apiGroup.Delete("/projects/:id/fields/:key", admin, r.Service.DeleteField)
+	}
+
+	// История изменений задачи
+	{
+		apiGroup.Get("/task/:id/history", read, r.Service.GetHistory)
+		apiGroup.Get("/task/:id/history/:rev", read, r.Service.GetRevision)
+		apiGroup.Post("/task/:id/revert/:rev", write, r.Service.RevertTask)
+	}
+
+	// Архив выполненных задач
+	{
+		apiGroup.Get("/archive", read, r.Service.GetArchive)
+		apiGroup.Post("/task/:id/unarchive", write, r.Service.UnarchiveTask)
+	}
+
+	// Корзина
+	{
+		apiGroup.Get("/trash", read, r.Service.GetTrash)
+		apiGroup.Post("/trash/:id/restore", write, r.Service.RestoreTask)
+		apiGroup.Delete("/trash/:id", write, r.Service.PurgeTask)
+	}
+
+	// Статусы задач и разрешённые переходы
+	apiGroup.Get("/workflow", read, r.Service.GetWorkflow)
+
+	// Зависимости между задачами
+	{
+		apiGroup.Post("/task/:id/dependencies", write, r.Service.AddDependency)
+		apiGroup.Get("/task/:id/dependencies", read, r.Service.GetDependencies)
+		apiGroup.Delete("/task/:id/dependencies/:blocker_id", write, r.Service.RemoveDependency)
+		apiGroup.Get("/tasks/order", read, r.Service.GetTaskOrder)
+	}
+
+	// Сводка оценок по иерархии задач
+	apiGroup.Get("/tasks/estimates", read, r.Service.GetEstimates)
+
+	// Комментарии к задачам
+	{
+		apiGroup.Post("/task/:id/comments", write, r.Service.AddComment)
+		apiGroup.Get("/task/:id/comments", read, r.Service.GetComments)
+		apiGroup.Put("/task/:id/comments/:cid", write, r.Service.UpdateComment)
+		apiGroup.Delete("/task/:id/comments/:cid", write, r.Service.DeleteComment)
+	}
+
+	// Учёт времени
+	{
+		apiGroup.Post("/task/:id/timer/start", write, r.Service.StartTimer)
+		apiGroup.Post("/task/:id/timer/stop", write, r.Service.StopTimer)
+		apiGroup.Post("/task/:id/worklogs", write, r.Service.AddWorklog)
+		apiGroup.Get("/task/:id/worklogs", read, r.Service.GetWorklogs)
+		apiGroup.Put("/task/:id/worklogs/:wid", write, r.Service.UpdateWorklog)
+		apiGroup.Delete("/task/:id/worklogs/:wid", write, r.Service.DeleteWorklog)
+		apiGroup.Get("/worklogs/report", read, r.Service.GetWorklogReport)
+	}
+
+	// Чек-лист задачи
+	{
+		apiGroup.Get("/task/:id/checklist", read, r.Service.GetChecklist)
+		apiGroup.Post("/task/:id/checklist", write, r.Service.AddChecklistItem)
+		apiGroup.Post("/task/:id/checklist/:iid/move", write, r.Service.MoveChecklistItem)
+		apiGroup.Post("/task/:id/checklist/:iid/toggle", write, r.Service.ToggleChecklistItem)
+		apiGroup.Delete("/task/:id/checklist/:iid", write, r.Service.RemoveChecklistItem)
+	}
+
+	// Вложения
+	{
+		apiGroup.Post("/task/:id/attachments", write, r.Service.UploadAttachment)
+		apiGroup.Get("/task/:id/attachments", read, r.Service.ListAttachments)
+		apiGroup.Get("/task/:id/attachments/:aid", read, r.Service.DownloadAttachment)
+		apiGroup.Delete("/task/:id/attachments/:aid", write, r.Service.DeleteAttachment)
+	}
+
+	// Журнал аудита
+	{
+		apiGroup.Get("/audit", admin, r.Service.GetAudit)
+		apiGroup.Get("/audit/verify", admin, r.Service.VerifyAudit)
+	}
+
+	// Управление API-ключами
+	adminGroup := apiGroup.Group("/admin", admin)
+	{
+		adminGroup.Post("/api-keys", r.Service.CreateAPIKey)
+		adminGroup.Get("/api-keys", r.Service.ListAPIKeys)
+		adminGroup.Delete("/api-keys/:id", r.Service.RevokeAPIKey)
 	}
 
 	return app
@@ -1,10 +1,122 @@
 package mw
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+const bearerPrefix = "Bearer "
+
+// AuthConfig - настройки авторизации для группы /v1
+type AuthConfig struct {
+	Tokens  []string               // статические токены из конфига
+	JWT     *auth.JWTVerifier      // nil, если JWT не настроен
+	APIKeys repos.APIKeyRepository // выданные через /v1/admin/api-keys ключи
+
+	ClientCertScopes []string // scope для вызовов без токена, но с проверенным клиентским сертификатом
+}
+
+// Authorization - проверка заголовка Authorization: Bearer <token>.
+// Принимает один из статических токенов (их несколько, чтобы ротировать без простоя),
+// выданный API-ключ или JWT от шлюза. Без заголовка вызывающим становится CN проверенного клиентского сертификата (mTLS).
+// Вызывающий сохраняется в контексте запроса, см. auth.FromCtx.
+func Authorization(cfg AuthConfig) fiber.Handler {
+	allowed := make([][]byte, 0, len(cfg.Tokens))
+	for _, t := range cfg.Tokens {
+		if t = strings.TrimSpace(t); t != "" {
+			allowed = append(allowed, []byte(t))
+		}
+	}
 
-func Authorization(token string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// проверка токена вторизации
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			id, ok := auth.FromClientCert(c.Context().TLSConnectionState(), cfg.ClientCertScopes)
+			if !ok {
+				return dto.UnauthorizedResponse(c)
+			}
+
+			auth.SetIdentity(c, id)
+
+			return c.Next()
+		}
+
+		token, ok := bearerToken(header)
+		if !ok {
+			return dto.UnauthorizedResponse(c)
+		}
+
+		// статические токены и ключи проверяются раньше JWT: токен из конфига может сам походить на JWT
+		if matchToken(allowed, []byte(token)) {
+			auth.SetIdentity(c, auth.Identity{Subject: auth.StaticSubject, Scopes: auth.AllScopes})
+
+			return c.Next()
+		}
+
+		if cfg.APIKeys != nil && auth.LooksLikeAPIKey(token) {
+			id, err := auth.AuthenticateAPIKey(c.Context(), cfg.APIKeys, token)
+			if err != nil {
+				return dto.UnauthorizedResponse(c)
+			}
+
+			auth.SetIdentity(c, id)
+
+			return c.Next()
+		}
+
+		if cfg.JWT != nil && auth.LooksLikeJWT(token) {
+			id, err := cfg.JWT.Verify(token)
+			if err != nil {
+				return dto.UnauthorizedResponse(c)
+			}
+
+			auth.SetIdentity(c, id)
+
+			return c.Next()
+		}
+
+		return dto.UnauthorizedResponse(c)
+	}
+}
+
+// RequireScope - доступ к маршруту только при наличии scope (см. auth.Identity.Allows)
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, ok := auth.FromCtx(c)
+		if !ok || !id.Allows(scope) {
+			return dto.InsufficientScopeError(c, scope)
+		}
+
 		return c.Next()
 	}
 }
+
+// bearerToken - достаёт токен из заголовка Authorization
+func bearerToken(header string) (string, bool) {
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[len(bearerPrefix):])
+	if token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// matchToken - сравнение за константное время, проходим по всем токенам без раннего выхода
+func matchToken(allowed [][]byte, token []byte) bool {
+	found := 0
+	for _, t := range allowed {
+		found |= subtle.ConstantTimeCompare(t, token)
+	}
+
+	return found == 1
+}
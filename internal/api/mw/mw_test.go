@@ -0,0 +1,205 @@
+package mw
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/internal/workflow"
+)
+
+// authApp - приложение с Authorization, в ответе - subject вызывающего
+func authApp(cfg AuthConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(Authorization(cfg))
+	app.Get("/", func(c *fiber.Ctx) error {
+		id, _ := auth.FromCtx(c)
+		return c.SendString(id.Subject)
+	})
+
+	return app
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		header string
+		want   int
+	}{
+		{"missing header", []string{"secret"}, "", fiber.StatusUnauthorized},
+		{"no bearer prefix", []string{"secret"}, "secret", fiber.StatusUnauthorized},
+		{"basic scheme", []string{"secret"}, "Basic secret", fiber.StatusUnauthorized},
+		{"empty token", []string{"secret"}, "Bearer   ", fiber.StatusUnauthorized},
+		{"wrong token", []string{"secret"}, "Bearer other", fiber.StatusUnauthorized},
+		{"token prefix", []string{"secret"}, "Bearer secr", fiber.StatusUnauthorized},
+		{"valid token", []string{"secret"}, "Bearer secret", fiber.StatusOK},
+		{"case-insensitive scheme", []string{"secret"}, "bearer secret", fiber.StatusOK},
+		{"rotation old token", []string{"old", "new"}, "Bearer old", fiber.StatusOK},
+		{"rotation new token", []string{"old", "new"}, "Bearer new", fiber.StatusOK},
+		{"rotated out token", []string{"new"}, "Bearer old", fiber.StatusUnauthorized},
+		{"blank tokens ignored", []string{" ", ""}, "Bearer  ", fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := authApp(AuthConfig{Tokens: tt.tokens})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationStaticTokenBeforeJWT(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(config.JWT{Secret: "jwt-secret"})
+	if err != nil {
+		t.Fatalf("create verifier: %v", err)
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "gateway-user",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("jwt-secret"))
+	if err != nil {
+		t.Fatalf("sign jwt: %v", err)
+	}
+
+	// статический токен с двумя точками похож на JWT, но должен приниматься как токен из конфига
+	app := authApp(AuthConfig{Tokens: []string{"static.token.value"}, JWT: verifier})
+
+	tests := []struct {
+		name    string
+		token   string
+		want    int
+		subject string
+	}{
+		{"static token with dots", "static.token.value", fiber.StatusOK, auth.StaticSubject},
+		{"valid jwt", signed, fiber.StatusOK, "gateway-user"},
+		{"invalid jwt", "other.token.value", fiber.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+
+			if tt.want != fiber.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+
+			if string(body) != tt.subject {
+				t.Fatalf("subject %q, want %q", body, tt.subject)
+			}
+		})
+	}
+}
+
+func TestAuthorizationAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	var wfCfg config.Workflow
+	if err := envconfig.Process("", &wfCfg); err != nil {
+		t.Fatalf("load workflow config: %v", err)
+	}
+
+	wf, err := workflow.New(wfCfg)
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+
+	keys := repos.NewMemory(wf)
+
+	// newKey - ключ для alice, сохранённый в хранилище
+	newKey := func(expiresAt *time.Time) (string, uuid.UUID) {
+		plain, hash, prefix, err := auth.GenerateAPIKey()
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+
+		key := repos.APIKey{Id: uuid.New(), Name: "test", Subject: "alice", Prefix: prefix, Hash: hash,
+			Scopes: []string{auth.ScopeTasksRead}, ExpiresAt: expiresAt}
+		if err = keys.CreateAPIKey(ctx, key); err != nil {
+			t.Fatalf("create key: %v", err)
+		}
+
+		return plain, key.Id
+	}
+
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+
+	valid, _ := newKey(&future)
+	expired, _ := newKey(&past)
+	revoked, revokedId := newKey(nil)
+
+	if err = keys.RevokeAPIKey(ctx, revokedId); err != nil {
+		t.Fatalf("revoke key: %v", err)
+	}
+
+	app := authApp(AuthConfig{Tokens: []string{"secret"}, APIKeys: keys})
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"valid key", valid, fiber.StatusOK},
+		{"expired key", expired, fiber.StatusUnauthorized},
+		{"revoked key", revoked, fiber.StatusUnauthorized},
+		{"unknown key", auth.APIKeyPrefix + "unknown", fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
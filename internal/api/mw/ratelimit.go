@@ -0,0 +1,130 @@
+package mw
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/ratelimit"
+)
+
+type routeLimit struct {
+	method   string
+	segments []string
+	limit    ratelimit.Limit
+}
+
+// RateLimitByIP - token bucket на IP клиента. Ставится перед Authorization,
+// поэтому запросы с неверным токеном тоже расходуют корзину и подбор токенов ограничен.
+func RateLimitByIP(cfg config.RateLimit) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	limiter := ratelimit.New()
+	limit := ratelimit.Limit{Burst: cfg.IPBurst, Refill: cfg.IPRefill}
+
+	return func(c *fiber.Ctx) error {
+		return allow(c, limiter, "ip:"+c.IP(), limit)
+	}
+}
+
+// RateLimit - token bucket на клиента: по вызывающему, которого установил Authorization, а без него - по IP.
+// Ставится после Authorization: непроверенный токен не даёт новой корзины.
+// Для маршрутов из cfg.Routes у клиента отдельная корзина со своим лимитом.
+func RateLimit(cfg config.RateLimit) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	limiter := ratelimit.New()
+	def := ratelimit.Limit{Burst: cfg.Burst, Refill: cfg.Refill}
+
+	routes := make([]routeLimit, 0, len(cfg.Routes))
+	for route, l := range cfg.Routes {
+		method, path, _ := strings.Cut(route, " ")
+		routes = append(routes, routeLimit{
+			method:   method,
+			segments: splitPath(path),
+			limit:    ratelimit.Limit{Burst: l.Burst, Refill: l.Refill},
+		})
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := clientKey(c)
+		limit := def
+
+		if r, ok := matchRoute(routes, c.Method(), c.Path()); ok {
+			key += "|" + r.method + " " + strings.Join(r.segments, "/")
+			limit = r.limit
+		}
+
+		return allow(c, limiter, key, limit)
+	}
+}
+
+// allow - списание токена и заголовки X-RateLimit-*; следующий лимитер перезаписывает заголовки своими
+func allow(c *fiber.Ctx, limiter *ratelimit.Limiter, key string, limit ratelimit.Limit) error {
+	res := limiter.Allow(key, limit)
+
+	c.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset.Seconds())))
+
+	if !res.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter.Seconds())))
+		return dto.TooManyRequests(c)
+	}
+
+	return c.Next()
+}
+
+// clientKey - проверенный вызывающий или IP клиента; статические токены - один вызывающий
+func clientKey(c *fiber.Ctx) string {
+	if id, ok := auth.FromCtx(c); ok && id.Subject != "" {
+		return "caller:" + id.Subject
+	}
+
+	return "ip:" + c.IP()
+}
+
+func matchRoute(routes []routeLimit, method, path string) (routeLimit, bool) {
+	segments := splitPath(path)
+
+	for _, r := range routes {
+		if r.method != method || len(r.segments) != len(segments) {
+			continue
+		}
+
+		matched := true
+		for i, s := range r.segments {
+			if !strings.HasPrefix(s, ":") && s != segments[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return r, true
+		}
+	}
+
+	return routeLimit{}, false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func ceilSeconds(s float64) int {
+	return int(math.Ceil(s))
+}
@@ -0,0 +1,152 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/volkowlad/week4/internal/config"
+)
+
+func TestRateLimitKeysOnCaller(t *testing.T) {
+	app := fiber.New()
+	app.Use(Authorization(AuthConfig{Tokens: []string{"old", "new"}}), RateLimit(config.RateLimit{Enabled: true, Burst: 2, Refill: 0.001}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(token string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	// случайные токены отклоняются до лимитера и не заводят корзин
+	for i := 0; i < 5; i++ {
+		if code := send(uuid.NewString()); code != fiber.StatusUnauthorized {
+			t.Fatalf("random token: status %d, want %d", code, fiber.StatusUnauthorized)
+		}
+	}
+
+	// токены ротации - один вызывающий и одна корзина
+	for i, token := range []string{"old", "new", "old"} {
+		want := fiber.StatusOK
+		if i == 2 {
+			want = fiber.StatusTooManyRequests
+		}
+
+		if code := send(token); code != want {
+			t.Fatalf("request %d: status %d, want %d", i+1, code, want)
+		}
+	}
+}
+
+func TestRateLimitByIPThrottlesFailedAuth(t *testing.T) {
+	cfg := config.RateLimit{Enabled: true, IPBurst: 3, IPRefill: 0.001}
+
+	app := fiber.New()
+	app.Use(RateLimitByIP(cfg), Authorization(AuthConfig{Tokens: []string{"secret"}}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(token string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	// подбор токена расходует корзину IP, после неё отказ уже до проверки токена
+	for i := 0; i < 3; i++ {
+		if code := send(uuid.NewString()); code != fiber.StatusUnauthorized {
+			t.Fatalf("guess %d: status %d, want %d", i+1, code, fiber.StatusUnauthorized)
+		}
+	}
+
+	if code := send("secret"); code != fiber.StatusTooManyRequests {
+		t.Fatalf("after guesses: status %d, want %d", code, fiber.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	cfg := config.RateLimit{
+		Enabled: true,
+		Burst:   2,
+		Refill:  0.5,
+		Routes:  config.RouteLimits{"GET /task/:id": {Burst: 1, Refill: 0.25}},
+	}
+
+	app := fiber.New()
+	app.Use(RateLimit(cfg))
+	app.Get("/tasks", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/task/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(path string) *http.Response {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		status     int
+		limit      string
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{"first default", "/tasks", fiber.StatusOK, "2", "1", "2", ""},
+		{"route override has its own bucket", "/task/1", fiber.StatusOK, "1", "0", "4", ""},
+		{"route override matches any id", "/task/2", fiber.StatusTooManyRequests, "1", "0", "4", "4"},
+		{"second default", "/tasks", fiber.StatusOK, "2", "0", "4", ""},
+		{"default exhausted", "/tasks", fiber.StatusTooManyRequests, "2", "0", "4", "2"},
+	}
+
+	for _, tt := range tests {
+		resp := send(tt.path)
+
+		got := []string{
+			resp.Header.Get("X-RateLimit-Limit"),
+			resp.Header.Get("X-RateLimit-Remaining"),
+			resp.Header.Get("X-RateLimit-Reset"),
+			resp.Header.Get(fiber.HeaderRetryAfter),
+		}
+		want := []string{tt.limit, tt.remaining, tt.reset, tt.retryAfter}
+
+		if resp.StatusCode != tt.status {
+			t.Fatalf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: headers %q, want %q", tt.name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+const (
+	// APIKeyPrefix - префикс выдаваемых ключей, по нему ключ отличается от статического токена
+	APIKeyPrefix = "tk_"
+
+	apiKeyBytes     = 32
+	apiKeyShownPart = len(APIKeyPrefix) + 6
+
+	// apiKeyTouchInterval - last_used_at обновляется не чаще раза в интервал, а не на каждый запрос
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	errAPIKeyRevoked = errors.New("api key is revoked")
+	errAPIKeyExpired = errors.New("api key is expired")
+)
+
+// GenerateAPIKey - новый ключ: открытый текст (показывается один раз), его хеш и префикс для списка
+func GenerateAPIKey() (plain, hash, prefix string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", errors.Wrap(err, "failed to generate api key")
+	}
+
+	plain = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	return plain, HashAPIKey(plain), plain[:apiKeyShownPart], nil
+}
+
+// HashAPIKey - SHA-256 от ключа; ключ случайный и длинный, поэтому соль не нужна
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// LooksLikeAPIKey - ключ выдан через /v1/admin/api-keys
+func LooksLikeAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// AuthenticateAPIKey - поиск ключа по хешу, отказ для отозванных и просроченных ключей
+func AuthenticateAPIKey(ctx context.Context, keys repos.APIKeyRepository, token string) (Identity, error) {
+	key, err := keys.GetAPIKeyByHash(ctx, HashAPIKey(token))
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return Identity{}, errAPIKeyRevoked
+	}
+
+	if key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt) {
+		return Identity{}, errAPIKeyExpired
+	}
+
+	// last_used_at - best effort, ошибка не должна мешать запросу
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) >= apiKeyTouchInterval {
+		_ = keys.TouchAPIKey(ctx, key.Id)
+	}
+
+	return Identity{Subject: key.Subject, Scopes: key.Scopes}, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// touchCounter - хранилище с одним ключом, считает обновления last_used_at
+type touchCounter struct {
+	repos.APIKeyRepository
+	key     repos.APIKey
+	touches int
+}
+
+func (c *touchCounter) GetAPIKeyByHash(_ context.Context, hash string) (repos.APIKey, error) {
+	if hash != c.key.Hash {
+		return repos.APIKey{}, myerr.ErrAPIKeyNotFound
+	}
+
+	return c.key, nil
+}
+
+func (c *touchCounter) TouchAPIKey(_ context.Context, _ uuid.UUID) error {
+	now := time.Now()
+	c.key.LastUsedAt = &now
+	c.touches++
+
+	return nil
+}
+
+func TestAuthenticateAPIKeyThrottlesTouch(t *testing.T) {
+	ctx := context.Background()
+
+	plain, hash, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	keys := &touchCounter{key: repos.APIKey{Id: uuid.New(), Subject: "alice", Hash: hash}}
+
+	for i := 0; i < 10; i++ {
+		if _, err = AuthenticateAPIKey(ctx, keys, plain); err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+	}
+
+	if keys.touches != 1 {
+		t.Fatalf("touches %d, want 1", keys.touches)
+	}
+
+	// после интервала ключ снова отмечается
+	stale := time.Now().Add(-apiKeyTouchInterval)
+	keys.key.LastUsedAt = &stale
+
+	if _, err = AuthenticateAPIKey(ctx, keys, plain); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+
+	if keys.touches != 2 {
+		t.Fatalf("touches %d, want 2", keys.touches)
+	}
+}
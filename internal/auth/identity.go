@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/tls"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const identityKey = "auth.identity"
+
+// StaticSubject - субъект для вызовов со статическим токеном из конфига
+const StaticSubject = "static-token"
+
+// Identity - кто вызывает API (субъект и выданные ему scope)
+type Identity struct {
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes,omitempty"`
+}
+
+// HasScope - проверка наличия scope у вызывающего
+func (i Identity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetIdentity - сохранение вызывающего в контексте запроса
+func SetIdentity(ctx *fiber.Ctx, id Identity) {
+	ctx.Locals(identityKey, id)
+}
+
+// FromCtx - получение вызывающего из контекста запроса
+func FromCtx(ctx *fiber.Ctx) (Identity, bool) {
+	id, ok := ctx.Locals(identityKey).(Identity)
+	return id, ok
+}
+
+// FromClientCert - вызывающий по проверенному клиентскому сертификату (mTLS), субъект - CN
+func FromClientCert(state *tls.ConnectionState, scopes []string) (Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	if cn == "" {
+		return Identity{}, false
+	}
+
+	return Identity{Subject: cn, Scopes: scopes}, true
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/config"
+)
+
+// JWTVerifier - проверка JWT от шлюза: HS256 по общему секрету, RS256/ES256 по ключам из JWKS
+type JWTVerifier struct {
+	secret []byte
+	keys   map[string]crypto.PublicKey
+	parser *jwt.Parser
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope  string   `json:"scope"`
+	Scopes []string `json:"scopes"`
+}
+
+// NewJWTVerifier - конструктор; возвращает nil, если JWT не настроен
+func NewJWTVerifier(cfg config.JWT) (*JWTVerifier, error) {
+	if cfg.Secret == "" && cfg.JWKSPath == "" {
+		return nil, nil
+	}
+
+	v := &JWTVerifier{}
+	methods := make([]string, 0, 3)
+
+	if cfg.Secret != "" {
+		v.secret = []byte(cfg.Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if cfg.JWKSPath != "" {
+		keys, err := loadJWKS(cfg.JWKSPath)
+		if err != nil {
+			return nil, err
+		}
+
+		v.keys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// LooksLikeJWT - грубая проверка формата, чтобы отличить JWT от статического токена
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify - проверка подписи и claims (exp, nbf, iss, aud), возвращает вызывающего
+func (v *JWTVerifier) Verify(token string) (Identity, error) {
+	var c claims
+
+	if _, err := v.parser.ParseWithClaims(token, &c, v.keyFunc); err != nil {
+		return Identity{}, errors.Wrap(err, "invalid jwt")
+	}
+
+	if c.Subject == "" {
+		return Identity{}, errors.New("invalid jwt: sub is required")
+	}
+
+	scopes := c.Scopes
+	if c.Scope != "" {
+		scopes = append(scopes, strings.Fields(c.Scope)...)
+	}
+
+	return Identity{Subject: c.Subject, Scopes: scopes}, nil
+}
+
+func (v *JWTVerifier) keyFunc(t *jwt.Token) (any, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret == nil {
+			return nil, errors.New("hmac is not configured")
+		}
+
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		key, err := v.publicKey(t)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, errors.New("key type does not match alg")
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, errors.New("key type does not match alg")
+			}
+		}
+
+		return key, nil
+	default:
+		return nil, errors.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
+
+func (v *JWTVerifier) publicKey(t *jwt.Token) (crypto.PublicKey, error) {
+	if len(v.keys) == 0 {
+		return nil, errors.New("jwks is not configured")
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, nil
+		}
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, errors.Errorf("unknown kid %q", kid)
+	}
+
+	return key, nil
+}
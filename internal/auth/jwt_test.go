@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/volkowlad/week4/internal/config"
+)
+
+const (
+	testSecret   = "hmac-secret"
+	testIssuer   = "https://gateway.example"
+	testAudience = "tasks-api"
+)
+
+// writeJWKS - JWKS-файл с ключами для проверки подписи
+func writeJWKS(t *testing.T, keys map[string]any) string {
+	t.Helper()
+
+	b64 := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	set := jwkSet{}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kid: kid, Kty: "RSA", Use: "sig", N: b64(k.N), E: b64(big.NewInt(int64(k.E)))})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kid: kid, Kty: "EC", Crv: "P-256", X: b64(k.X), Y: b64(k.Y)})
+		case jwk:
+			set.Keys = append(set.Keys, k)
+		}
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	return path
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal rsa public key: %v", err)
+	}
+	rsaPublicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER})
+
+	jwks := writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+
+	full, err := NewJWTVerifier(config.JWT{Secret: testSecret, JWKSPath: jwks, Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatalf("create verifier: %v", err)
+	}
+
+	jwksOnly, err := NewJWTVerifier(config.JWT{JWKSPath: jwks})
+	if err != nil {
+		t.Fatalf("create verifier: %v", err)
+	}
+
+	now := time.Now()
+
+	// valid - claims, которые принимает full; mutate меняет копию
+	valid := func(mutate func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":    "alice",
+			"iss":    testIssuer,
+			"aud":    testAudience,
+			"exp":    now.Add(time.Hour).Unix(),
+			"scope":  "tasks:read tasks:write",
+			"scopes": []string{"tasks:admin"},
+		}
+
+		if mutate != nil {
+			mutate(c)
+		}
+
+		return c
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key any, c jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign %s: %v", method.Alg(), err)
+		}
+
+		return signed
+	}
+
+	tests := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+		ok       bool
+	}{
+		{"hs256", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(nil)), true},
+		{"rs256 from jwks", full, sign(jwt.SigningMethodRS256, "rsa", rsaKey, valid(nil)), true},
+		{"es256 from jwks", full, sign(jwt.SigningMethodES256, "ec", ecKey, valid(nil)), true},
+		{"hs256 wrong secret", full, sign(jwt.SigningMethodHS256, "", []byte("other"), valid(nil)), false},
+		{"rs256 wrong key", full, sign(jwt.SigningMethodRS256, "rsa", otherRSA, valid(nil)), false},
+		{"expired", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			c["exp"] = now.Add(-time.Minute).Unix()
+		})), false},
+		{"without exp", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			delete(c, "exp")
+		})), false},
+		{"not yet valid", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			c["nbf"] = now.Add(time.Hour).Unix()
+		})), false},
+		{"wrong issuer", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			c["iss"] = "https://evil.example"
+		})), false},
+		{"wrong audience", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			c["aud"] = "other-api"
+		})), false},
+		{"missing sub", full, sign(jwt.SigningMethodHS256, "", []byte(testSecret), valid(func(c jwt.MapClaims) {
+			delete(c, "sub")
+		})), false},
+		{"unknown kid", full, sign(jwt.SigningMethodRS256, "retired", rsaKey, valid(nil)), false},
+		{"no kid with several keys", full, sign(jwt.SigningMethodRS256, "", rsaKey, valid(nil)), false},
+		{"rs256 with ec kid", full, sign(jwt.SigningMethodRS256, "ec", rsaKey, valid(nil)), false},
+		{"es256 with rsa kid", full, sign(jwt.SigningMethodES256, "rsa", ecKey, valid(nil)), false},
+		{"hs256 signed with rsa public key", full, sign(jwt.SigningMethodHS256, "rsa", rsaPublicPEM, valid(nil)), false},
+		{"hs256 without secret", jwksOnly, sign(jwt.SigningMethodHS256, "rsa", rsaPublicPEM, valid(nil)), false},
+		{"alg none", full, sign(jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, valid(nil)), false},
+		{"rs512 not allowed", full, sign(jwt.SigningMethodRS512, "rsa", rsaKey, valid(nil)), false},
+		{"without issuer and audience check", jwksOnly, sign(jwt.SigningMethodES256, "ec", ecKey, valid(func(c jwt.MapClaims) {
+			delete(c, "iss")
+			delete(c, "aud")
+		})), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.verifier.Verify(tt.token)
+			if !tt.ok {
+				if err == nil {
+					t.Fatalf("token accepted: %+v", id)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+
+			if id.Subject != "alice" {
+				t.Fatalf("subject %q, want alice", id.Subject)
+			}
+
+			for _, scope := range []string{ScopeTasksRead, ScopeTasksWrite, ScopeTasksAdmin} {
+				if !slices.Contains(id.Scopes, scope) {
+					t.Fatalf("scopes %v, want %s", id.Scopes, scope)
+				}
+			}
+		})
+	}
+}
+
+func TestNewJWTVerifierConfig(t *testing.T) {
+	if v, err := NewJWTVerifier(config.JWT{}); v != nil || err != nil {
+		t.Fatalf("not configured: %v, %v", v, err)
+	}
+
+	tests := []struct {
+		name string
+		keys map[string]any
+	}{
+		{"unsupported key type", map[string]any{"oct": jwk{Kid: "oct", Kty: "oct"}}},
+		{"unsupported curve", map[string]any{"ec": jwk{Kid: "ec", Kty: "EC", Crv: "P-192"}}},
+		{"point not on curve", map[string]any{"ec": jwk{Kid: "ec", Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"}}},
+		{"bad modulus", map[string]any{"rsa": jwk{Kid: "rsa", Kty: "RSA", N: "!", E: "AQAB"}}},
+		{"only encryption keys", map[string]any{"enc": jwk{Kid: "enc", Kty: "RSA", Use: "enc", N: "AQ", E: "AQAB"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTVerifier(config.JWT{JWKSPath: writeJWKS(t, tt.keys)}); err == nil {
+				t.Fatalf("invalid jwks accepted")
+			}
+		})
+	}
+
+	if _, err := NewJWTVerifier(config.JWT{JWKSPath: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Fatalf("missing jwks file accepted")
+	}
+}
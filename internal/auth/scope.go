@@ -0,0 +1,39 @@
+package auth
+
+// Scope доступа к маршрутам; старший scope включает младшие: admin > write > read
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeTasksAdmin = "tasks:admin"
+)
+
+var scopeLevel = map[string]int{
+	ScopeTasksRead:  1,
+	ScopeTasksWrite: 2,
+	ScopeTasksAdmin: 3,
+}
+
+// AllScopes - scope для статических токенов из конфига
+var AllScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeTasksAdmin}
+
+// ValidScope - известен ли scope
+func ValidScope(scope string) bool {
+	_, ok := scopeLevel[scope]
+	return ok
+}
+
+// Allows - есть ли у вызывающего требуемый scope (напрямую или через старший)
+func (i Identity) Allows(scope string) bool {
+	need, known := scopeLevel[scope]
+	if !known {
+		return i.HasScope(scope)
+	}
+
+	for _, s := range i.Scopes {
+		if scopeLevel[s] >= need {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/config"
+)
+
+// ErrNotFound - объекта с таким ключом нет
+var ErrNotFound = errors.New("blob not found")
+
+// Store - хранилище содержимого файлов; метаданные хранятся отдельно, в репозитории задач
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error // отсутствие объекта ошибкой не считается
+}
+
+// New - хранилище по настройке ATTACHMENTS_STORE
+func New(ctx context.Context, cfg config.Attachments) (Store, error) {
+	switch cfg.Store {
+	case "local":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(ctx, cfg.S3)
+	default:
+		return nil, errors.Errorf("unknown attachments store %q", cfg.Store)
+	}
+}
@@ -0,0 +1,179 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/config"
+)
+
+// Reloader - держит актуальные сертификат сервера и CA клиентов.
+// Новые соединения получают текущие файлы через GetConfigForClient,
+// поэтому перезагрузка не трогает уже открытые соединения и запросы в них.
+type Reloader struct {
+	certFile, keyFile, caFile string
+	requireClientCert         bool
+	log                       *zap.SugaredLogger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader - конструктор, сразу загружает файлы и возвращает ошибку, если они некорректны
+func NewReloader(cfg config.Rest, logger *zap.SugaredLogger) (*Reloader, error) {
+	r := &Reloader{
+		certFile:          cfg.TLSCertFile,
+		keyFile:           cfg.TLSKeyFile,
+		caFile:            cfg.TLSClientCAFile,
+		requireClientCert: cfg.TLSRequireClientCert,
+		log:               logger,
+	}
+
+	if r.requireClientCert && r.caFile == "" {
+		return nil, errors.New("client certificates are required, but TLS_CLIENT_CA_FILE is not set")
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// TLSConfig - конфигурация для tls.NewListener
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+
+			if r.caPool != nil {
+				cfg.ClientCAs = r.caPool
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				if r.requireClientCert {
+					cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+
+			return cfg, nil
+		},
+	}
+}
+
+// Reload - перечитать сертификат, ключ и CA; при ошибке остаются старые
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load server certificate")
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read client CA file")
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client CA file has no certificates")
+		}
+	}
+
+	modTimes := r.currentModTimes()
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.caPool = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Watch - перезагрузка по SIGHUP и при изменении файлов (проверка раз в interval).
+// interval <= 0 отключает проверку файлов, остаётся только SIGHUP
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// из nil-канала ничего не приходит, без тикера select ждёт только SIGHUP и ctx
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload("SIGHUP")
+		case <-tick:
+			if r.changed() {
+				r.reload("files changed")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload(reason string) {
+	if err := r.Reload(); err != nil {
+		r.log.Errorw("Failed to reload TLS certificates, keeping the previous ones", "reason", reason, "error", err)
+		return
+	}
+
+	r.log.Infow("TLS certificates reloaded", "reason", reason)
+}
+
+func (r *Reloader) changed() bool {
+	current := r.currentModTimes()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for file, t := range current {
+		if !t.Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reloader) currentModTimes() map[string]time.Time {
+	times := make(map[string]time.Time, 3)
+
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		if info, err := os.Stat(file); err == nil {
+			times[file] = info.ModTime()
+		}
+	}
+
+	return times
+}
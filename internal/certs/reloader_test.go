@@ -0,0 +1,332 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/api/mw"
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/config"
+)
+
+// issued - сертификат с ключом; signer == nil - самоподписанный CA
+type issued struct {
+	cert *x509.Certificate
+	der  []byte
+	key  *ecdsa.PrivateKey
+}
+
+func issue(t *testing.T, cn string, signer *issued, usage x509.ExtKeyUsage) issued {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parent, signKey := tmpl, key
+	if signer == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		tmpl.ExtKeyUsage = nil
+	} else {
+		parent, signKey = signer.cert, signer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	return issued{cert: cert, der: der, key: key}
+}
+
+func (c issued) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})
+}
+
+func (c issued) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c issued) tlsCert(t *testing.T) tls.Certificate {
+	pair, err := tls.X509KeyPair(c.certPEM(), c.keyPEM(t))
+	if err != nil {
+		t.Fatalf("key pair: %v", err)
+	}
+
+	return pair
+}
+
+// writeFile - запись с заметно другим временем изменения, чтобы changed() его увидел
+func writeFile(t *testing.T, path string, data []byte, mtime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes %s: %v", path, err)
+	}
+}
+
+// tlsFiles - пути к файлам сервера и CA клиентов во временном каталоге
+type tlsFiles struct {
+	cert, key, ca string
+}
+
+func newTLSFiles(t *testing.T, server, ca issued) (tlsFiles, config.Rest) {
+	dir := t.TempDir()
+	files := tlsFiles{
+		cert: filepath.Join(dir, "server.crt"),
+		key:  filepath.Join(dir, "server.key"),
+		ca:   filepath.Join(dir, "ca.crt"),
+	}
+
+	mtime := time.Now().Add(-time.Hour)
+	writeFile(t, files.cert, server.certPEM(), mtime)
+	writeFile(t, files.key, server.keyPEM(t), mtime)
+	writeFile(t, files.ca, ca.certPEM(), mtime)
+
+	return files, config.Rest{TLSCertFile: files.cert, TLSKeyFile: files.key, TLSClientCAFile: files.ca}
+}
+
+// served - сертификат и CA клиентов, которые получит новое соединение
+func served(t *testing.T, r *Reloader) ([]byte, *x509.CertPool) {
+	t.Helper()
+
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("config for client: %v", err)
+	}
+
+	return cfg.Certificates[0].Certificate[0], cfg.ClientCAs
+}
+
+func trusts(pool *x509.CertPool, client issued) bool {
+	_, err := client.cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	return err == nil
+}
+
+func TestWatchPicksUpChangedFiles(t *testing.T) {
+	caA := issue(t, "ca-a", nil, 0)
+	caB := issue(t, "ca-b", nil, 0)
+	first := issue(t, "localhost", &caA, x509.ExtKeyUsageServerAuth)
+	second := issue(t, "localhost", &caA, x509.ExtKeyUsageServerAuth)
+	clientB := issue(t, "svc", &caB, x509.ExtKeyUsageClientAuth)
+
+	files, cfg := newTLSFiles(t, first, caA)
+
+	r, err := NewReloader(cfg, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+
+	if cert, pool := served(t, r); !bytes.Equal(cert, first.der) || trusts(pool, clientB) {
+		t.Fatalf("initial files not served")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	now := time.Now()
+	writeFile(t, files.cert, second.certPEM(), now)
+	writeFile(t, files.key, second.keyPEM(t), now)
+	writeFile(t, files.ca, caB.certPEM(), now)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cert, pool := served(t, r)
+		if bytes.Equal(cert, second.der) && trusts(pool, clientB) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("changed files not picked up")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadKeepsPreviousOnError(t *testing.T) {
+	ca := issue(t, "ca", nil, 0)
+	server := issue(t, "localhost", &ca, x509.ExtKeyUsageServerAuth)
+	other := issue(t, "localhost", &ca, x509.ExtKeyUsageServerAuth)
+	client := issue(t, "svc", &ca, x509.ExtKeyUsageClientAuth)
+
+	tests := []struct {
+		name  string
+		write func(files tlsFiles)
+	}{
+		{"broken certificate", func(files tlsFiles) {
+			writeFile(t, files.cert, []byte("not a certificate"), time.Now())
+		}},
+		{"key from another pair", func(files tlsFiles) {
+			writeFile(t, files.key, other.keyPEM(t), time.Now())
+		}},
+		{"empty CA", func(files tlsFiles) {
+			writeFile(t, files.ca, nil, time.Now())
+		}},
+		{"missing CA", func(files tlsFiles) {
+			if err := os.Remove(files.ca); err != nil {
+				t.Fatalf("remove CA: %v", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, cfg := newTLSFiles(t, server, ca)
+
+			r, err := NewReloader(cfg, zap.NewNop().Sugar())
+			if err != nil {
+				t.Fatalf("new reloader: %v", err)
+			}
+
+			tt.write(files)
+
+			if err = r.Reload(); err == nil {
+				t.Fatalf("reload accepted broken files")
+			}
+
+			if cert, pool := served(t, r); !bytes.Equal(cert, server.der) || !trusts(pool, client) {
+				t.Fatalf("previous pair not kept")
+			}
+		})
+	}
+}
+
+func TestClientCertIdentity(t *testing.T) {
+	ca := issue(t, "ca", nil, 0)
+	stranger := issue(t, "stranger-ca", nil, 0)
+	server := issue(t, "localhost", &ca, x509.ExtKeyUsageServerAuth)
+
+	_, cfg := newTLSFiles(t, server, ca)
+
+	r, err := NewReloader(cfg, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(mw.Authorization(mw.AuthConfig{Tokens: []string{"secret"}, ClientCertScopes: []string{auth.ScopeTasksRead}}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		id, _ := auth.FromCtx(c)
+		// subject и право на запись: сертификату выдан только tasks:read
+		return c.SendString(id.Subject + " " + strconv.FormatBool(id.Allows(auth.ScopeTasksWrite)))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go func() { _ = app.Listener(tls.NewListener(ln, r.TLSConfig())) }()
+	defer func() { _ = app.Shutdown() }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(client *issued) (int, string, error) {
+		tlsCfg := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+		if client != nil {
+			// сертификат отправляется, даже если сервер не называл его CA среди допустимых
+			cert := client.tlsCert(t)
+			tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return &cert, nil
+			}
+		}
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}, Timeout: 5 * time.Second}
+
+		resp, err := httpClient.Get("https://" + ln.Addr().String() + "/")
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, string(body), err
+	}
+
+	client := issue(t, "billing-service", &ca, x509.ExtKeyUsageClientAuth)
+
+	code, body, err := get(&client)
+	if err != nil || code != fiber.StatusOK || body != "billing-service false" {
+		t.Fatalf("verified client: %d %q %v", code, body, err)
+	}
+
+	if code, _, err = get(nil); err != nil || code != fiber.StatusUnauthorized {
+		t.Fatalf("without certificate: %d %v, want %d", code, err, fiber.StatusUnauthorized)
+	}
+
+	foreign := issue(t, "billing-service", &stranger, x509.ExtKeyUsageClientAuth)
+	if _, _, err = get(&foreign); err == nil {
+		t.Fatalf("certificate from an unknown CA accepted")
+	}
+}
+
+func TestWatchWithoutPolling(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			(&Reloader{}).Watch(ctx, interval)
+		}()
+
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("interval %v: Watch did not stop", interval)
+		}
+	}
+}
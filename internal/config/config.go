@@ -3,16 +3,93 @@ package config
 import "time"
 
 type AppConfig struct {
-	LogLevel string `envconfig:"LOG_LEVEL" default:"info"`
-	Rest     Rest
-	Postgres PostgreSQL
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`
+	Rest      Rest
+	Postgres  PostgreSQL
+	JWT       JWT
+	RateLimit RateLimit
+	Overdue   Overdue
+	Files     Attachments
+	Workflow  Workflow
+	Trash     Trash
+	Archive   Archive
 }
 
 type Rest struct {
 	ListenAddress string        `envconfig:"PORT" required:"true"`
 	WriteTimeout  time.Duration `envconfig:"WRITE_TIMEOUT" required:"true"`
 	ServerName    string        `envconfig:"SERVER_NAME" required:"true"`
-	Token         string        `envconfig:"TOKEN" required:"true"`
+	Tokens        []string      `envconfig:"TOKEN" required:"true"` // несколько токенов через запятую для ротации
+
+	// TLS включается, если заданы сертификат и ключ; с TLSClientCAFile - проверка клиентских сертификатов (mTLS)
+	TLSCertFile          string        `envconfig:"TLS_CERT_FILE"`
+	TLSKeyFile           string        `envconfig:"TLS_KEY_FILE"`
+	TLSClientCAFile      string        `envconfig:"TLS_CLIENT_CA_FILE"`
+	TLSRequireClientCert bool          `envconfig:"TLS_REQUIRE_CLIENT_CERT" default:"false"`
+	TLSClientScopes      []string      `envconfig:"TLS_CLIENT_SCOPES" default:"tasks:read"` // scope для вызовов по клиентскому сертификату, запись - только явно
+	TLSReloadInterval    time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"30s"`      // как часто проверять файлы на изменения, 0 - только по SIGHUP
+}
+
+// TLSEnabled - сервер слушает по TLS
+func (r Rest) TLSEnabled() bool {
+	return r.TLSCertFile != "" && r.TLSKeyFile != ""
+}
+
+type JWT struct {
+	Secret   string        `envconfig:"JWT_SECRET"`    // общий секрет для HS256
+	JWKSPath string        `envconfig:"JWT_JWKS_PATH"` // локальный JWKS с ключами для RS256/ES256
+	Issuer   string        `envconfig:"JWT_ISSUER"`
+	Audience string        `envconfig:"JWT_AUDIENCE"`
+	Leeway   time.Duration `envconfig:"JWT_LEEWAY" default:"30s"`
+}
+
+type RateLimit struct {
+	Enabled bool        `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+	Burst   int         `envconfig:"RATE_LIMIT_BURST" default:"60"`
+	Refill  float64     `envconfig:"RATE_LIMIT_REFILL" default:"1"` // токенов в секунду
+	Routes  RouteLimits `envconfig:"RATE_LIMIT_ROUTES"`             // "GET /v1/tasks=10/0.5;POST /v1/create_task=5/1"
+
+	// лимит по IP до авторизации: неудачные попытки подобрать токен тоже тратят корзину
+	IPBurst  int     `envconfig:"RATE_LIMIT_IP_BURST" default:"120"`
+	IPRefill float64 `envconfig:"RATE_LIMIT_IP_REFILL" default:"2"`
+}
+
+type Overdue struct {
+	Enabled  bool          `envconfig:"OVERDUE_SWEEP_ENABLED" default:"true"`
+	Interval time.Duration `envconfig:"OVERDUE_SWEEP_INTERVAL" default:"1m"`
+}
+
+// Trash - окончательное удаление задач из корзины по истечении срока хранения
+type Trash struct {
+	PurgeEnabled  bool          `envconfig:"TRASH_PURGE_ENABLED" default:"true"`
+	Retention     time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
+	PurgeInterval time.Duration `envconfig:"TRASH_PURGE_INTERVAL" default:"1h"`
+}
+
+// Archive - перенос в архив задач, выполненных больше After назад
+type Archive struct {
+	Enabled  bool          `envconfig:"ARCHIVE_ENABLED" default:"true"`
+	After    time.Duration `envconfig:"ARCHIVE_AFTER" default:"2160h"`
+	Interval time.Duration `envconfig:"ARCHIVE_INTERVAL" default:"1h"`
+}
+
+// Attachments - хранилище вложений и ограничения на загружаемые файлы
+type Attachments struct {
+	Store        string   `envconfig:"ATTACHMENTS_STORE" default:"local"` // local или s3
+	Dir          string   `envconfig:"ATTACHMENTS_DIR" default:"./data/attachments"`
+	MaxSize      int64    `envconfig:"ATTACHMENTS_MAX_SIZE" default:"10485760"`                                                                                        // байт
+	AllowedTypes []string `envconfig:"ATTACHMENTS_ALLOWED_TYPES" default:"text/plain,image/png,image/jpeg,image/gif,application/pdf,application/json,application/zip"` // допустим шаблон image/*
+	S3           S3
+}
+
+// S3 - S3-совместимое хранилище (AWS S3, MinIO)
+type S3 struct {
+	Endpoint  string `envconfig:"S3_ENDPOINT"` // host:port, например localhost:9000 для MinIO
+	Region    string `envconfig:"S3_REGION"`
+	Bucket    string `envconfig:"S3_BUCKET" default:"task-attachments"`
+	AccessKey string `envconfig:"S3_ACCESS_KEY"`
+	SecretKey string `envconfig:"S3_SECRET_KEY"`
+	UseSSL    bool   `envconfig:"S3_USE_SSL" default:"true"`
 }
 
 type PostgreSQL struct {
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Validate - лимиты по умолчанию и по IP должны пропускать запросы и пополняться
+func (r RateLimit) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+
+	if r.Burst < 1 {
+		return errors.Errorf("invalid RATE_LIMIT_BURST %d, must be positive", r.Burst)
+	}
+
+	if r.Refill <= 0 {
+		return errors.Errorf("invalid RATE_LIMIT_REFILL %v, must be positive", r.Refill)
+	}
+
+	if r.IPBurst < 1 {
+		return errors.Errorf("invalid RATE_LIMIT_IP_BURST %d, must be positive", r.IPBurst)
+	}
+
+	if r.IPRefill <= 0 {
+		return errors.Errorf("invalid RATE_LIMIT_IP_REFILL %v, must be positive", r.IPRefill)
+	}
+
+	return nil
+}
+
+// RouteLimit - переопределение лимита для одного маршрута
+type RouteLimit struct {
+	Burst  int
+	Refill float64
+}
+
+// RouteLimits - лимиты по маршрутам, ключ - "МЕТОД /путь" как в роутере (например "GET /v1/task/:id").
+// Свой формат, потому что стандартный разбор map в envconfig делит пары по ":".
+type RouteLimits map[string]RouteLimit
+
+// Decode - разбор строки вида "GET /v1/tasks=10/0.5;POST /v1/create_task=5/1"
+func (r *RouteLimits) Decode(value string) error {
+	limits := make(RouteLimits)
+
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(item, "=")
+		if !ok {
+			return errors.Errorf("invalid route limit %q, want \"METHOD /path=burst/refill\"", item)
+		}
+
+		method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok {
+			return errors.Errorf("invalid route %q, want \"METHOD /path\"", route)
+		}
+
+		burst, refill, ok := strings.Cut(limit, "/")
+		if !ok {
+			return errors.Errorf("invalid limit %q, want \"burst/refill\"", limit)
+		}
+
+		b, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil || b < 1 {
+			return errors.Errorf("invalid burst %q", burst)
+		}
+
+		f, err := strconv.ParseFloat(strings.TrimSpace(refill), 64)
+		if err != nil || f <= 0 {
+			return errors.Errorf("invalid refill %q", refill)
+		}
+
+		limits[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = RouteLimit{Burst: b, Refill: f}
+	}
+
+	*r = limits
+
+	return nil
+}
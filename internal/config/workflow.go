@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Workflow - статусы задач и разрешённые переходы между ними
+type Workflow struct {
+	Statuses    []string    `envconfig:"WORKFLOW_STATUSES" default:"new,in_progress,done,reopened"` // первый - статус новой задачи
+	Done        []string    `envconfig:"WORKFLOW_DONE_STATUSES" default:"done"`                     // выполнена: не просрочена и не блокирует другие
+	Transitions Transitions `envconfig:"WORKFLOW_TRANSITIONS" default:"new->in_progress,done;in_progress->new,done;done->reopened;reopened->in_progress,done"`
+}
+
+// Transitions - разрешённые переходы: статус -> статусы, в которые из него можно перейти
+type Transitions map[string][]string
+
+// Decode - разбор строки вида "new->in_progress,done;done->reopened"
+func (t *Transitions) Decode(value string) error {
+	transitions := make(Transitions)
+
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(item, "->")
+		from = strings.TrimSpace(from)
+		if !ok || from == "" {
+			return errors.Errorf("invalid transition %q, want \"from->to1,to2\"", item)
+		}
+
+		for _, status := range strings.Split(to, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				return errors.Errorf("invalid transition %q: empty target status", item)
+			}
+
+			transitions[from] = append(transitions[from], status)
+		}
+	}
+
+	*t = transitions
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransitionsDecode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Transitions
+	}{
+		{"", Transitions{}},
+		{"new->done", Transitions{"new": {"done"}}},
+		{" new -> in_progress , done ; done->reopened; ", Transitions{"new": {"in_progress", "done"}, "done": {"reopened"}}},
+		{"new->done;new->review", Transitions{"new": {"done", "review"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var got Transitions
+			if err := got.Decode(tt.value); err != nil {
+				t.Fatalf("Decode(%q): %v", tt.value, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Decode(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionsDecodeErrors(t *testing.T) {
+	for _, value := range []string{"new", "new=>done", "->done", "new->", "new->done,,reopened"} {
+		t.Run(value, func(t *testing.T) {
+			var got Transitions
+			if err := got.Decode(value); err == nil {
+				t.Fatalf("Decode(%q) = %v, want error", value, got)
+			}
+		})
+	}
+}
@@ -7,10 +7,33 @@ import (
 const (
 	FieldBadFormat     = "FIELD_BADFORMAT"
 	FieldIncorrect     = "FIELD_INCORRECT"
+	Unauthorized       = "UNAUTHORIZED"
+	InsufficientScope  = "INSUFFICIENT_SCOPE"
+	RateLimited        = "RATE_LIMITED"
+	TaskCycle          = "TASK_CYCLE"
+	OpenSubtasks       = "OPEN_SUBTASKS"
+	DependencyCycle    = "DEPENDENCY_CYCLE"
+	TaskBlocked        = "TASK_BLOCKED"
+	NotCommentAuthor   = "NOT_COMMENT_AUTHOR"
+	FileTooLarge       = "FILE_TOO_LARGE"
+	UnsupportedType    = "UNSUPPORTED_TYPE"
+	TaskExists         = "TASK_EXISTS"
+	ProjectKeyTaken    = "PROJECT_KEY_TAKEN"
+	ProjectArchived    = "PROJECT_ARCHIVED"
+	FieldKeyTaken      = "FIELD_KEY_TAKEN"
+	ProjectNotEmpty    = "PROJECT_NOT_EMPTY"
+	InvalidTransition  = "INVALID_TRANSITION"
+	NotArchived        = "NOT_ARCHIVED"
+	TimerRunning       = "TIMER_RUNNING"
+	NoTimer            = "NO_TIMER"
+	NotWorklogAuthor   = "NOT_WORKLOG_AUTHOR"
 	ServiceUnavailable = "SERVICE_UNAVAILABLE"
 	InternalError      = "Service is currently unavailable. Please try again later."
 	NoContent          = "No Data"
 	ContentError       = "Service is available, but no data with this ID"
+	UnauthorizedError  = "Missing or invalid authorization token"
+	ScopeError         = "Token does not have the required scope: "
+	RateLimitError     = "Too many requests. Please retry later."
 )
 
 type Response struct {
@@ -63,3 +86,73 @@ func WrongType(ctx *fiber.Ctx) error {
 		},
 	})
 }
+
+func UnauthorizedResponse(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusUnauthorized).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: Unauthorized,
+			Desc: UnauthorizedError,
+		},
+	})
+}
+
+func InsufficientScopeError(ctx *fiber.Ctx, scope string) error {
+	return ctx.Status(fiber.StatusForbidden).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: InsufficientScope,
+			Desc: ScopeError + scope,
+		},
+	})
+}
+
+func TooManyRequests(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusTooManyRequests).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: RateLimited,
+			Desc: RateLimitError,
+		},
+	})
+}
+
+func Conflict(ctx *fiber.Ctx, code, desc string) error {
+	return ctx.Status(fiber.StatusConflict).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: code,
+			Desc: desc,
+		},
+	})
+}
+
+func Forbidden(ctx *fiber.Ctx, code, desc string) error {
+	return ctx.Status(fiber.StatusForbidden).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: code,
+			Desc: desc,
+		},
+	})
+}
+
+func RequestEntityTooLarge(ctx *fiber.Ctx, desc string) error {
+	return ctx.Status(fiber.StatusRequestEntityTooLarge).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: FileTooLarge,
+			Desc: desc,
+		},
+	})
+}
+
+func UnsupportedMediaType(ctx *fiber.Ctx, desc string) error {
+	return ctx.Status(fiber.StatusUnsupportedMediaType).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: UnsupportedType,
+			Desc: desc,
+		},
+	})
+}
@@ -4,7 +4,45 @@ import "github.com/pkg/errors"
 
 var (
 	ErrTaskNotFound    = errors.New("task not found")
+	ErrTaskExists      = errors.New("task with this id already exists")
 	ErrInvalidTaskType = errors.New("invalid task type")
 	ErrTitle           = errors.New("title is required")
 	ErrRange           = errors.New("page out of range")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+	ErrParentNotFound  = errors.New("parent task not found")
+	ErrTaskCycle       = errors.New("task can not be a subtask of itself or of its subtasks")
+	ErrOpenSubtasks    = errors.New("task has subtasks that are not done")
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	ErrNoDependency    = errors.New("dependency not found")
+	ErrTaskBlocked     = errors.New("task is blocked by tasks that are not done")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrCommentAuthor   = errors.New("only the author can change a comment")
+	ErrNoAttachment    = errors.New("attachment not found")
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectKeyTaken = errors.New("project key is already used")
+	ErrProjectArchived = errors.New("project is archived")
+	ErrProjectNotEmpty = errors.New("project has tasks (including tasks in trash)")
+	ErrNotAssigned     = errors.New("task is not assigned to this user")
+	ErrUnknownStatus   = errors.New("unknown task status")
+	ErrTransition      = errors.New("status transition is not allowed")
+	ErrNoRevision      = errors.New("revision not found")
+	ErrNotArchived     = errors.New("task is not archived")
+	ErrTimerRunning    = errors.New("user already has a running timer")
+	ErrNoTimer         = errors.New("no running timer on this task")
+	ErrWorklogNotFound = errors.New("worklog not found")
+	ErrWorklogAuthor   = errors.New("only the author can change a worklog")
+	ErrWorklogRange    = errors.New("worklog must end after it starts")
+	ErrNoChecklistItem = errors.New("checklist item not found")
+	ErrFieldNotFound   = errors.New("custom field not found")
+	ErrFieldKeyTaken   = errors.New("custom field key already exists in project")
 )
+
+// FieldValueError - значение пользовательского поля не прошло проверку по схеме проекта,
+// текст в формате pkg/validator
+type FieldValueError struct {
+	Err error
+}
+
+func (e *FieldValueError) Error() string {
+	return e.Err.Error()
+}
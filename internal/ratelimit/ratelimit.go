@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// idleTTL - через сколько удаляются корзины клиентов, которые давно не приходили
+const idleTTL = 10 * time.Minute
+
+// Limit - параметры корзины: ёмкость и скорость пополнения (токенов в секунду)
+type Limit struct {
+	Burst  int
+	Refill float64
+}
+
+// Result - итог проверки для заголовков X-RateLimit-* и Retry-After
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // когда появится следующий токен (только если Allowed == false)
+	Reset      time.Duration // когда корзина наполнится полностью
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter - token bucket на каждый ключ (клиент или клиент + маршрут)
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func New() *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow - списание одного токена из корзины key
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Refill)
+	b.last = now
+
+	res := Result{Limit: limit.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Refill)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Refill)
+
+	return res
+}
+
+// sweep - очистка простаивающих корзин не чаще раза в idleTTL
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+func seconds(s float64) time.Duration {
+	if math.IsInf(s, 0) || math.IsNaN(s) {
+		return idleTTL
+	}
+
+	return time.Duration(s * float64(time.Second))
+}
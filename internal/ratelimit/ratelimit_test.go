@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock - управляемое время для корзин
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter() (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+	l := New()
+	l.now = func() time.Time { return clock.now }
+	l.lastSweep = clock.now
+
+	return l, clock
+}
+
+func TestAllowBurst(t *testing.T) {
+	l, _ := newTestLimiter()
+	limit := Limit{Burst: 3, Refill: 1}
+
+	for i := 0; i < 3; i++ {
+		res := l.Allow("a", limit)
+		if !res.Allowed {
+			t.Fatalf("request %d rejected", i+1)
+		}
+
+		if res.Limit != 3 || res.Remaining != 2-i {
+			t.Fatalf("request %d: limit %d, remaining %d", i+1, res.Limit, res.Remaining)
+		}
+	}
+
+	res := l.Allow("a", limit)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request over burst: %+v", res)
+	}
+
+	if res.RetryAfter != time.Second {
+		t.Fatalf("retry after %v, want %v", res.RetryAfter, time.Second)
+	}
+
+	if res.Reset != 3*time.Second {
+		t.Fatalf("reset %v, want %v", res.Reset, 3*time.Second)
+	}
+
+	// у другого ключа своя корзина
+	if !l.Allow("b", limit).Allowed {
+		t.Fatalf("other key rejected")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l, clock := newTestLimiter()
+	limit := Limit{Burst: 2, Refill: 0.5}
+
+	l.Allow("a", limit)
+	l.Allow("a", limit)
+
+	clock.advance(time.Second)
+
+	res := l.Allow("a", limit)
+	if res.Allowed {
+		t.Fatalf("allowed after half a token")
+	}
+
+	if res.RetryAfter != time.Second {
+		t.Fatalf("retry after %v, want %v", res.RetryAfter, time.Second)
+	}
+
+	clock.advance(time.Second)
+
+	if !l.Allow("a", limit).Allowed {
+		t.Fatalf("rejected after a full token")
+	}
+
+	// корзина не наполняется больше ёмкости
+	clock.advance(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if !l.Allow("a", limit).Allowed {
+			t.Fatalf("request %d rejected after a long pause", i+1)
+		}
+	}
+
+	if l.Allow("a", limit).Allowed {
+		t.Fatalf("burst exceeded after a long pause")
+	}
+}
+
+func TestSweepIdleBuckets(t *testing.T) {
+	l, clock := newTestLimiter()
+	limit := Limit{Burst: 1, Refill: 0.001}
+
+	l.Allow("idle", limit)
+
+	clock.advance(idleTTL + time.Second)
+	l.Allow("active", limit)
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Fatalf("idle bucket kept")
+	}
+
+	if _, ok := l.buckets["active"]; !ok {
+		t.Fatalf("active bucket removed")
+	}
+}
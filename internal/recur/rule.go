@@ -0,0 +1,372 @@
+package recur
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "time/tzdata" // часовые пояса не зависят от системной базы
+
+	"github.com/pkg/errors"
+)
+
+// Подмножество RRULE из RFC 5545: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (для DAILY и WEEKLY),
+// BYMONTHDAY (для MONTHLY, отрицательные - от конца месяца), COUNT и UNTIL.
+// Неделя начинается с понедельника (WKST=MO).
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// ErrInvalidRule - правило не разобрано или не поддерживается
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// maxSkips - сколько периодов подряд можно пропустить в поисках даты (BYMONTHDAY=31 и т.п.)
+const maxSkips = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule - разобранное правило повторения
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday // по порядку с понедельника
+	ByMonthDay []int
+	Count      int        // 0 - без ограничения
+	Until      *time.Time // UTC, включительно
+}
+
+const rrulePrefix = "RRULE:"
+
+// Parse - разбор строки вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10", префикс "RRULE:" допустим
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	seen := make(map[string]bool)
+
+	s = strings.TrimSpace(s)
+	if len(s) >= len(rrulePrefix) && strings.EqualFold(s[:len(rrulePrefix)], rrulePrefix) {
+		s = s[len(rrulePrefix):]
+	}
+
+	if s == "" {
+		return Rule{}, errors.Wrap(ErrInvalidRule, "empty rule")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+
+		if !ok || key == "" || value == "" {
+			return Rule{}, errors.Wrapf(ErrInvalidRule, "malformed part %q", part)
+		}
+
+		if seen[key] {
+			return Rule{}, errors.Wrapf(ErrInvalidRule, "duplicate %s", key)
+		}
+		seen[key] = true
+
+		var err error
+
+		switch key {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return Rule{}, errors.Wrapf(ErrInvalidRule, "unsupported FREQ %q", value)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			rule.Interval, err = positive(value, 1000)
+		case "COUNT":
+			rule.Count, err = positive(value, 10000)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			err = errors.Errorf("unsupported part %s", key)
+		}
+
+		if err != nil {
+			return Rule{}, errors.Wrapf(ErrInvalidRule, "%s: %v", key, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, errors.Wrap(ErrInvalidRule, "FREQ is required")
+	}
+
+	if rule.Count > 0 && rule.Until != nil {
+		return Rule{}, errors.Wrap(ErrInvalidRule, "COUNT and UNTIL can not be used together")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq == Monthly {
+		return Rule{}, errors.Wrap(ErrInvalidRule, "BYDAY is not supported with FREQ=MONTHLY")
+	}
+
+	if len(rule.ByMonthDay) > 0 && rule.Freq != Monthly {
+		return Rule{}, errors.Wrap(ErrInvalidRule, "BYMONTHDAY is supported only with FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// String - каноническая запись правила
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, strings.ToUpper(d.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next - следующее повторение после current (повторение номер seq, начиная с 1) для серии,
+// начатой в start. Дата считается в часовом поясе loc, время суток берётся из start:
+// переход на летнее время и попадание в несуществующий час не сдвигают следующие повторения.
+// ok = false, если повторения закончились.
+func (r Rule) Next(start, current time.Time, seq int, loc *time.Location) (time.Time, bool) {
+	if r.Count > 0 && seq >= r.Count {
+		return time.Time{}, false
+	}
+
+	start, current = start.In(loc), current.In(loc)
+
+	var next time.Time
+	var ok bool
+
+	switch r.Freq {
+	case Daily:
+		next, ok = r.nextDaily(start, current)
+	case Weekly:
+		next, ok = r.nextWeekly(start, current)
+	case Monthly:
+		next, ok = r.nextMonthly(start, current)
+	}
+
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+func (r Rule) nextDaily(start, current time.Time) (time.Time, bool) {
+	for i := 1; i <= maxSkips; i++ {
+		next := at(start, current.Year(), current.Month(), current.Day()+i*r.Interval)
+		if len(r.ByDay) == 0 || hasWeekday(r.ByDay, next.Weekday()) {
+			return next, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r Rule) nextWeekly(start, current time.Time) (time.Time, bool) {
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []time.Weekday{start.Weekday()}
+	}
+
+	// позже на этой же неделе
+	for _, d := range days {
+		if offset(d) > offset(current.Weekday()) {
+			return at(start, current.Year(), current.Month(), current.Day()+offset(d)-offset(current.Weekday())), true
+		}
+	}
+
+	// первый день через INTERVAL недель
+	monday := current.Day() - offset(current.Weekday())
+
+	return at(start, current.Year(), current.Month(), monday+7*r.Interval+offset(days[0])), true
+}
+
+func (r Rule) nextMonthly(start, current time.Time) (time.Time, bool) {
+	days := r.ByMonthDay
+	if len(days) == 0 {
+		days = []int{start.Day()}
+	}
+
+	for i := 0; i <= maxSkips; i++ {
+		year, month := current.Year(), current.Month()+time.Month(i*r.Interval)
+
+		for _, day := range resolveMonthDays(days, year, month) {
+			if i == 0 && day <= current.Day() {
+				continue
+			}
+
+			return at(start, year, month, day), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// resolveMonthDays - дни месяца по порядку; несуществующие (31 в апреле) пропускаются, как в RFC 5545
+func resolveMonthDays(days []int, year int, month time.Month) []int {
+	n := daysIn(year, month)
+	resolved := make([]int, 0, len(days))
+
+	for _, d := range days {
+		if d < 0 {
+			d = n + d + 1
+		}
+
+		if d >= 1 && d <= n {
+			resolved = append(resolved, d)
+		}
+	}
+
+	sort.Ints(resolved)
+
+	return resolved
+}
+
+// at - дата year-month-day (с нормализацией) со временем суток и поясом clock.
+// Время, которого нет из-за перевода часов, считается по смещению до перевода (как в RFC 5545):
+// 02:30 в день перехода на летнее время в New York становится 03:30.
+func at(clock time.Time, year int, month time.Month, day int) time.Time {
+	loc := clock.Location()
+	t := time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), loc)
+
+	if t.Hour() == clock.Hour() && t.Minute() == clock.Minute() {
+		return t
+	}
+
+	// переводы часов бывают не чаще раза в сутки, сутки назад - смещение до перевода
+	wall := time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), time.UTC)
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+
+	return wall.Add(-time.Duration(before) * time.Second).In(loc)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// offset - номер дня недели с понедельника
+func offset(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+func hasWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+func positive(value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("expected an integer from 1 to %d", max)
+	}
+
+	return n, nil
+}
+
+const untilLayout = "20060102T150405Z"
+
+func parseUntil(value string) (*time.Time, error) {
+	for _, layout := range []string{untilLayout, "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// дата без времени - включительно до конца дня (UTC)
+				t = t.Add(24*time.Hour - time.Second)
+			}
+
+			return &t, nil
+		}
+	}
+
+	return nil, errors.New("expected YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+func parseByDay(value string) ([]time.Weekday, error) {
+	seen := make(map[time.Weekday]bool)
+
+	for _, v := range strings.Split(value, ",") {
+		d, ok := weekdays[strings.TrimSpace(v)]
+		if !ok {
+			return nil, errors.Errorf("unknown weekday %q", v)
+		}
+		seen[d] = true
+	}
+
+	days := make([]time.Weekday, 0, len(seen))
+	for d := range seen {
+		days = append(days, d)
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return offset(days[i]) < offset(days[j])
+	})
+
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	seen := make(map[int]bool)
+	days := make([]int, 0)
+
+	for _, v := range strings.Split(value, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || d == 0 || d < -31 || d > 31 {
+			return nil, errors.Errorf("invalid month day %q", v)
+		}
+
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+
+	sort.Ints(days)
+
+	return days, nil
+}
@@ -0,0 +1,187 @@
+package recur
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"empty", ""},
+		{"prefix only", "RRULE:"},
+		{"no FREQ", "INTERVAL=2"},
+		{"unsupported FREQ", "FREQ=YEARLY"},
+		{"part without value", "FREQ=DAILY;COUNT"},
+		{"empty value", "FREQ=DAILY;COUNT="},
+		{"duplicate part", "FREQ=DAILY;FREQ=WEEKLY"},
+		{"zero INTERVAL", "FREQ=DAILY;INTERVAL=0"},
+		{"INTERVAL too large", "FREQ=DAILY;INTERVAL=1001"},
+		{"negative COUNT", "FREQ=DAILY;COUNT=-1"},
+		{"malformed UNTIL", "FREQ=DAILY;UNTIL=2025"},
+		{"COUNT with UNTIL", "FREQ=DAILY;COUNT=2;UNTIL=20250101"},
+		{"unknown weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"BYDAY with MONTHLY", "FREQ=MONTHLY;BYDAY=MO"},
+		{"BYMONTHDAY with WEEKLY", "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{"BYMONTHDAY zero", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"BYMONTHDAY out of range", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"BYMONTHDAY negative out of range", "FREQ=MONTHLY;BYMONTHDAY=-32"},
+		{"unsupported WKST", "FREQ=WEEKLY;WKST=SU"},
+		{"unsupported part", "FREQ=DAILY;BYHOUR=9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.rule); !errors.Is(err, ErrInvalidRule) {
+				t.Fatalf("Parse(%q) = %v, want %v", tt.rule, err, ErrInvalidRule)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"rrule:freq=weekly;byday=fr,mo,fr;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1,15;COUNT=3", "FREQ=MONTHLY;BYMONTHDAY=-1,15;COUNT=3"},
+		{"FREQ=DAILY;UNTIL=20250105", "FREQ=DAILY;UNTIL=20250105T235959Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			if got := rule.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		loc   *time.Location
+		want  []string // повторения, включая start, в поясе loc
+	}{
+		{
+			name:  "BYMONTHDAY=31 skips short months",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=5",
+			start: time.Date(2025, time.January, 31, 10, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-31 10:00", "2025-03-31 10:00", "2025-05-31 10:00", "2025-07-31 10:00", "2025-08-31 10:00"},
+		},
+		{
+			name:  "BYMONTHDAY=-1 is the last day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=4",
+			start: time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2024-01-31 10:00", "2024-02-29 10:00", "2024-03-31 10:00", "2024-04-30 10:00"},
+		},
+		{
+			name:  "DST gap shifts only the missing hour",
+			rule:  "FREQ=DAILY;COUNT=4",
+			start: time.Date(2025, time.March, 8, 2, 30, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-03-08 02:30", "2025-03-09 03:30", "2025-03-10 02:30", "2025-03-11 02:30"},
+		},
+		{
+			name:  "DST end keeps wall clock",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			start: time.Date(2025, time.October, 26, 9, 0, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-10-26 09:00", "2025-11-02 09:00", "2025-11-09 09:00"},
+		},
+		{
+			name:  "COUNT stops the series",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-01 09:00", "2025-01-02 09:00", "2025-01-03 09:00"},
+		},
+		{
+			name:  "UNTIL date includes the whole day",
+			rule:  "FREQ=DAILY;INTERVAL=2;UNTIL=20250105",
+			start: time.Date(2025, time.January, 1, 23, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-01 23:00", "2025-01-03 23:00", "2025-01-05 23:00"},
+		},
+		{
+			name:  "UNTIL time is inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20250103T090000Z",
+			start: time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-01 09:00", "2025-01-02 09:00", "2025-01-03 09:00"},
+		},
+		{
+			name:  "WEEKLY with INTERVAL and BYDAY",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=5",
+			start: time.Date(2025, time.January, 6, 9, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-06 09:00", "2025-01-10 09:00", "2025-01-20 09:00", "2025-01-24 09:00", "2025-02-03 09:00"},
+		},
+		{
+			name:  "WEEKLY across a month boundary",
+			rule:  "FREQ=WEEKLY;BYDAY=TU,SU;COUNT=4",
+			start: time.Date(2025, time.January, 28, 9, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-28 09:00", "2025-02-02 09:00", "2025-02-04 09:00", "2025-02-09 09:00"},
+		},
+		{
+			name:  "DAILY with BYDAY skips weekends",
+			rule:  "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=4",
+			start: time.Date(2025, time.January, 9, 9, 0, 0, 0, time.UTC),
+			loc:   time.UTC,
+			want:  []string{"2025-01-09 09:00", "2025-01-10 09:00", "2025-01-13 09:00", "2025-01-14 09:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			got := []string{tt.start.In(tt.loc).Format("2006-01-02 15:04")}
+
+			// с запасом: лишнее повторение после конца серии - ошибка
+			for current := tt.start; len(got) <= len(tt.want); {
+				next, ok := rule.Next(tt.start, current, len(got), tt.loc)
+				if !ok {
+					break
+				}
+
+				got = append(got, next.In(tt.loc).Format("2006-01-02 15:04"))
+				current = next
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
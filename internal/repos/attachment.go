@@ -0,0 +1,23 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment - метаданные вложения; содержимое лежит в blob.Store по ключу BlobKey
+type Attachment struct {
+	Id          uuid.UUID `json:"id"`
+	TaskId      uuid.UUID `json:"task_id"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	SHA256      string    `json:"sha256"` // hex
+	CreatedAt   time.Time `json:"created"`
+}
+
+// BlobKey - ключ содержимого вложения в хранилище файлов
+func (a Attachment) BlobKey() string {
+	return "tasks/" + a.TaskId.String() + "/" + a.Id.String()
+}
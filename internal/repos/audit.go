@@ -0,0 +1,153 @@
+package repos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// SystemActor - автор изменений, которые делают фоновые обработчики
+const SystemActor = "system"
+
+// Действия над задачами, которые попадают в журнал аудита
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"  // перенос в корзину
+	AuditRestore = "restore" // возврат из корзины
+	AuditPurge   = "purge"   // окончательное удаление
+
+	// Изменения частей задачи, снимки - изменённый объект
+	AuditCommentAdd       = "comment_add"
+	AuditCommentUpdate    = "comment_update"
+	AuditCommentDelete    = "comment_delete"
+	AuditAttachmentAdd    = "attachment_add"
+	AuditAttachmentDelete = "attachment_delete"
+	AuditChecklist        = "checklist"      // снимки - пункты чек-листа целиком
+	AuditWorklogAdd       = "worklog_add"    // в том числе запуск таймера
+	AuditWorklogUpdate    = "worklog_update" // в том числе остановка таймера
+	AuditWorklogDelete    = "worklog_delete"
+	AuditDependencyAdd    = "dependency_add"
+	AuditDependencyRemove = "dependency_remove"
+)
+
+// AuditEntry - запись журнала аудита. Каждая запись содержит хеш предыдущей,
+// поэтому изменение или удаление записи в середине журнала обнаруживается проверкой цепочки.
+type AuditEntry struct {
+	Seq       int64           `json:"seq"`
+	TaskId    uuid.UUID       `json:"task_id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"` // снимок задачи (или её части) до изменения
+	After     json.RawMessage `json:"after,omitempty"`  // снимок задачи (или её части) после изменения
+	CreatedAt time.Time       `json:"created"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditFilter - фильтры для выборки журнала, пустые поля не учитываются
+type AuditFilter struct {
+	TaskId *uuid.UUID
+	Actor  string
+	From   *time.Time
+	To     *time.Time
+	Page   int
+	Limit  int
+}
+
+// newAuditEntry - запись журнала без хешей и времени, их выставляет хранилище.
+// before и after - снимки объекта, nil - снимка нет (создание, удаление)
+func newAuditEntry(actor, action string, taskId uuid.UUID, before, after any) (AuditEntry, error) {
+	entry := AuditEntry{
+		TaskId: taskId,
+		Actor:  actor,
+		Action: action,
+	}
+
+	var err error
+
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return entry, errors.Wrap(err, "failed to marshal audit snapshot")
+		}
+	}
+
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return entry, errors.Wrap(err, "failed to marshal audit snapshot")
+		}
+	}
+
+	return entry, nil
+}
+
+// AuditHash - хеш записи вместе с хешем предыдущей записи
+func AuditHash(prevHash string, e AuditEntry) string {
+	payload, _ := json.Marshal(struct {
+		PrevHash  string          `json:"prev_hash"`
+		TaskId    uuid.UUID       `json:"task_id"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		CreatedAt string          `json:"created"`
+	}{
+		PrevHash:  prevHash,
+		TaskId:    e.TaskId,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		Before:    e.Before,
+		After:     e.After,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain - проверка цепочки записей (в порядке seq).
+// Возвращает seq первой записи, на которой цепочка нарушена, или 0, если всё в порядке.
+func VerifyAuditChain(entries []AuditEntry) int64 {
+	prev := ""
+
+	for _, e := range entries {
+		if e.PrevHash != prev || AuditHash(prev, e) != e.Hash {
+			return e.Seq
+		}
+
+		prev = e.Hash
+	}
+
+	return 0
+}
+
+// matches - соответствие записи фильтру (для хранилища в памяти)
+func (f AuditFilter) matches(e AuditEntry) bool {
+	if f.TaskId != nil && e.TaskId != *f.TaskId {
+		return false
+	}
+
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+
+	if f.From != nil && e.CreatedAt.Before(*f.From) {
+		return false
+	}
+
+	if f.To != nil && e.CreatedAt.After(*f.To) {
+		return false
+	}
+
+	return true
+}
+
+// stampAudit - время записи с точностью до микросекунд, как хранит PostgreSQL
+func stampAudit() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,78 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditTaskMutations(t *testing.T) {
+	ctx := context.Background()
+
+	for name, rep := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			id, blocker := uuid.New(), uuid.New()
+			for _, task := range []uuid.UUID{id, blocker} {
+				if err := rep.CreateTask(ctx, alice, TaskCreate{Id: task, Title: "task"}); err != nil {
+					t.Fatalf("create task: %v", err)
+				}
+			}
+
+			if _, err := rep.AddComment(ctx, alice, Comment{Id: uuid.New(), TaskId: id, Author: alice, Body: "body"}); err != nil {
+				t.Fatalf("add comment: %v", err)
+			}
+
+			// повторная зависимость ничего не меняет и второй записи не даёт
+			for i := 0; i < 2; i++ {
+				if err := rep.AddDependency(ctx, alice, Dependency{TaskId: id, BlockerId: blocker}); err != nil {
+					t.Fatalf("add dependency: %v", err)
+				}
+			}
+
+			start := time.Now().Add(-time.Hour).UTC()
+			end := start.Add(30 * time.Minute)
+			if _, err := rep.AddWorklog(ctx, alice, Worklog{Id: uuid.New(), TaskId: id, User: alice, Start: start, End: &end}); err != nil {
+				t.Fatalf("add worklog: %v", err)
+			}
+
+			if _, err := rep.UpdateTask(ctx, alice, UpdateTask{Title: "updated"}, id); err != nil {
+				t.Fatalf("update task: %v", err)
+			}
+
+			if err := rep.DeleteTask(ctx, alice, id); err != nil {
+				t.Fatalf("delete task: %v", err)
+			}
+
+			entries, err := rep.ListAudit(ctx, AuditFilter{TaskId: &id, Page: 1, Limit: 100})
+			if err != nil {
+				t.Fatalf("list audit: %v", err)
+			}
+
+			want := []string{AuditCreate, AuditCommentAdd, AuditDependencyAdd, AuditWorklogAdd, AuditUpdate, AuditDelete}
+			if len(entries) != len(want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+			}
+
+			for i, e := range entries {
+				if e.Action != want[i] || e.Actor != alice {
+					t.Fatalf("entry %d: %s by %s, want %s by %s", i, e.Action, e.Actor, want[i], alice)
+				}
+			}
+
+			if update := entries[4]; len(update.Before) == 0 || len(update.After) == 0 {
+				t.Fatalf("update entry without snapshots: %+v", update)
+			}
+
+			chain, err := rep.AuditChain(ctx)
+			if err != nil {
+				t.Fatalf("audit chain: %v", err)
+			}
+
+			if seq := VerifyAuditChain(chain); seq != 0 {
+				t.Fatalf("audit chain broken at %d", seq)
+			}
+		})
+	}
+}
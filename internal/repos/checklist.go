@@ -0,0 +1,76 @@
+package repos
+
+import (
+	"github.com/google/uuid"
+)
+
+// ChecklistItem - пункт чек-листа задачи
+type ChecklistItem struct {
+	Id       uuid.UUID `json:"id"`
+	Text     string    `json:"text"`
+	Done     bool      `json:"done"`
+	Position int       `json:"position"` // с 1, без пропусков
+}
+
+// ChecklistProgress - сколько пунктов чек-листа отмечено из всех
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// progressOf - прогресс чек-листа, nil для пустого
+func progressOf(items []ChecklistItem) *ChecklistProgress {
+	if len(items) == 0 {
+		return nil
+	}
+
+	progress := &ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+
+	return progress
+}
+
+// moveItem - новый чек-лист, где пункт id стоит на позиции position (за пределами - в начало или конец)
+func moveItem(items []ChecklistItem, id uuid.UUID, position int) ([]ChecklistItem, bool) {
+	from := itemIndex(items, id)
+	if from < 0 {
+		return nil, false
+	}
+
+	to := min(max(position, 1), len(items)) - 1
+
+	moved := make([]ChecklistItem, 0, len(items))
+	rest := append(append([]ChecklistItem{}, items[:from]...), items[from+1:]...)
+	moved = append(moved, rest[:to]...)
+	moved = append(moved, items[from])
+	moved = append(moved, rest[to:]...)
+
+	return renumber(moved), true
+}
+
+// resetChecklist - копия чек-листа для следующего повторения: новые id, пункты не отмечены
+func resetChecklist(items []ChecklistItem) []ChecklistItem {
+	if len(items) == 0 {
+		return nil
+	}
+
+	reset := make([]ChecklistItem, 0, len(items))
+	for _, item := range items {
+		reset = append(reset, ChecklistItem{Id: uuid.New(), Text: item.Text, Position: item.Position})
+	}
+
+	return reset
+}
+
+// renumber - позиции по порядку с 1
+func renumber(items []ChecklistItem) []ChecklistItem {
+	for i := range items {
+		items[i].Position = i + 1
+	}
+
+	return items
+}
@@ -0,0 +1,17 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment - комментарий к задаче
+type Comment struct {
+	Id        uuid.UUID  `json:"id"`
+	TaskId    uuid.UUID  `json:"task_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created"`
+	EditedAt  *time.Time `json:"edited,omitempty"` // nil - комментарий не редактировался
+}
@@ -1,28 +1,181 @@
 package repos
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/volkowlad/week4/internal/workflow"
 )
 
 type Task struct {
-	Id          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created"`
-	UpdatedAt   time.Time `json:"updated"`
+	Id          uuid.UUID   `json:"id"`
+	OwnerId     string      `json:"owner_id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Status      string      `json:"status"`
+	DueAt       *time.Time  `json:"due_at,omitempty"`
+	Overdue     bool        `json:"overdue"` // выставляет фоновый обработчик просроченных задач
+	Tags        []string    `json:"tags"`
+	Assignees   []string    `json:"assignees"` // subject исполнителей по алфавиту
+	ParentId    *uuid.UUID  `json:"parent_id,omitempty"`
+	ProjectId   uuid.UUID   `json:"project_id"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+	Estimate
+	Progress    *ChecklistProgress `json:"checklist,omitempty"` // nil - чек-листа нет
+	Checklist   []ChecklistItem    `json:"-"`                   // пункты в самой задаче хранит только repMemory
+	Fields      map[string]any     `json:"fields,omitempty"`    // значения пользовательских полей проекта
+	CreatedAt   time.Time          `json:"created"`
+	UpdatedAt   time.Time          `json:"updated"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty"`   // в корзине с этого момента
+	CompletedAt *time.Time         `json:"completed_at,omitempty"` // переведена в выполненный статус
+	ArchivedAt  *time.Time         `json:"archived_at,omitempty"`  // в архиве, в обычные списки не попадает
 }
 
 type TaskCreate struct {
-	Id          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+	Id          uuid.UUID      `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	DueAt       *time.Time     `json:"due_at"`
+	Tags        []string       `json:"tags"`
+	ParentId    *uuid.UUID     `json:"parent_id"`
+	ProjectId   *uuid.UUID     `json:"project_id"` // nil - проект владельца по умолчанию
+	Recurrence  *Recurrence    `json:"recurrence"`
+	Fields      map[string]any `json:"fields"` // проверяются по схеме проекта
+	Estimate
 }
 
 type UpdateTask struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	ClearDescription bool           `json:"clear_description"` // сделать описание пустым
+	Status           string         `json:"status"`
+	DueAt            *time.Time     `json:"due_at"`
+	ClearDueAt       bool           `json:"clear_due_at"`     // снять срок
+	Tags             []string       `json:"tags"`             // nil - не менять, пустой список - снять все теги
+	ParentId         *uuid.UUID     `json:"parent_id"`        // перенести в подзадачи другой задачи
+	ClearParent      bool           `json:"clear_parent"`     // сделать задачей верхнего уровня
+	ProjectId        *uuid.UUID     `json:"project_id"`       // перенести в другой проект
+	Recurrence       *Recurrence    `json:"recurrence"`       // новая серия повторений
+	ClearRecurrence  bool           `json:"clear_recurrence"` // больше не повторять
+	Estimate                        // nil - не менять
+	ClearPoints      bool           `json:"clear_estimate_points"`  // снять оценку в пунктах
+	ClearMinutes     bool           `json:"clear_estimate_minutes"` // снять оценку во времени
+	Fields           map[string]any `json:"fields"`                 // nil - не менять, nil в значении - снять значение
+}
+
+// Estimate - оценка задачи, nil - не оценена
+type Estimate struct {
+	Points  *int `json:"estimate_points,omitempty"`
+	Minutes *int `json:"estimate_minutes,omitempty"`
+}
+
+// TaskFilter - постраничная выборка задач с фильтрами, пустые поля не учитываются
+type TaskFilter struct {
+	Page      int
+	Limit     int
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Overdue   bool // только просроченные и не выполненные на момент Now
+	Now       time.Time
+	Tag       string
+	ProjectId *uuid.UUID
+	Assignee  string
+	Archived  bool              // true - только архивные задачи, false - только активные
+	Fields    map[string]string // значения пользовательских полей в текстовом виде
+}
+
+// matches - соответствие задачи фильтру (для хранилища в памяти)
+func (f TaskFilter) matches(t *Task, wf *workflow.Workflow) bool {
+	if f.DueBefore != nil && (t.DueAt == nil || !t.DueAt.Before(*f.DueBefore)) {
+		return false
+	}
+
+	if f.DueAfter != nil && (t.DueAt == nil || !t.DueAt.After(*f.DueAfter)) {
+		return false
+	}
+
+	if f.Overdue && !isOverdue(t, f.Now, wf) {
+		return false
+	}
+
+	if f.Tag != "" && !containsString(t.Tags, f.Tag) {
+		return false
+	}
+
+	if f.ProjectId != nil && t.ProjectId != *f.ProjectId {
+		return false
+	}
+
+	if f.Assignee != "" && !containsString(t.Assignees, f.Assignee) {
+		return false
+	}
+
+	if (t.ArchivedAt != nil) != f.Archived {
+		return false
+	}
+
+	for key, want := range f.Fields {
+		if value, ok := t.Fields[key]; !ok || fieldText(value) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isOverdue - срок прошёл, а задача не выполнена
+func isOverdue(t *Task, now time.Time, wf *workflow.Workflow) bool {
+	return t.DueAt != nil && t.DueAt.Before(now) && !wf.IsDone(t.Status)
+}
+
+// Dependency - задача TaskId не может начаться, пока не выполнена BlockerId
+type Dependency struct {
+	TaskId    uuid.UUID `json:"task_id"`
+	BlockerId uuid.UUID `json:"blocker_id"`
+}
+
+// TagCount - тег и число задач с ним
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTags - теги без повторов и по алфавиту; никогда не nil
+func NormalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !containsString(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKey - выданный API-ключ; в хранилище лежит только хеш ключа
+type APIKey struct {
+	Id         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Subject    string     `json:"subject"` // от чьего имени действует ключ
+	Prefix     string     `json:"prefix"`  // начало ключа, чтобы его можно было узнать в списке
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created"`
 }
@@ -0,0 +1,213 @@
+package repos
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+// Типы пользовательских полей
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldEnum   = "enum"
+	FieldDate   = "date"
+	FieldBool   = "bool"
+)
+
+// FieldDateLayout - формат значений полей типа date
+const FieldDateLayout = "2006-01-02"
+
+// CustomField - пользовательское поле задач проекта; ключ уникален в пределах проекта, тип не меняется
+type CustomField struct {
+	Id        uuid.UUID  `json:"id"`
+	ProjectId uuid.UUID  `json:"project_id"`
+	Key       string     `json:"key"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Required  bool       `json:"required"`
+	Min       *float64   `json:"min,omitempty"` // string - длина, number - значение
+	Max       *float64   `json:"max,omitempty"`
+	MinDate   *time.Time `json:"min_date,omitempty"` // только для date
+	MaxDate   *time.Time `json:"max_date,omitempty"`
+	Options   []string   `json:"options,omitempty"` // допустимые значения enum
+	CreatedAt time.Time  `json:"created"`
+	UpdatedAt time.Time  `json:"updated"`
+}
+
+// applyFields - значения полей задачи после изменения: stored - прежние значения, values - из запроса,
+// nil в values снимает значение. Значения полей, которых нет в схеме defs, отбрасываются;
+// required - проверять ли, что заданы все обязательные поля
+func applyFields(ctx context.Context, defs []CustomField, stored, values map[string]any, required bool) (map[string]any, error) {
+	byKey := make(map[string]CustomField, len(defs))
+	for _, def := range defs {
+		byKey[def.Key] = def
+	}
+
+	result := make(map[string]any, len(stored)+len(values))
+	for key, value := range stored {
+		if _, ok := byKey[key]; ok {
+			result[key] = value
+		}
+	}
+
+	// ключи по порядку, чтобы при нескольких ошибках ответ не менялся от запроса к запросу
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		def, ok := byKey[key]
+		if !ok {
+			return nil, &myerr.FieldValueError{Err: validator.FieldError(validator.ErrUnknownField, fieldName(key))}
+		}
+
+		if values[key] == nil {
+			delete(result, key)
+			continue
+		}
+
+		value, err := def.check(ctx, values[key])
+		if err != nil {
+			return nil, &myerr.FieldValueError{Err: err}
+		}
+
+		result[key] = value
+	}
+
+	if required {
+		for _, def := range defs {
+			if _, ok := result[def.Key]; def.Required && !ok {
+				return nil, &myerr.FieldValueError{Err: validator.FieldError(validator.ErrFieldRequired, fieldName(def.Key))}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// check - значение поля, приведённое к виду для хранения, или ошибка в формате pkg/validator
+func (f CustomField) check(ctx context.Context, value any) (any, error) {
+	name := fieldName(f.Key)
+
+	switch f.Type {
+	case FieldString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		return s, validateBounds(ctx, name, s, boundsTag(f.Min, "min", f.Max, "max"))
+	case FieldNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		return n, validateBounds(ctx, name, n, boundsTag(f.Min, "gte", f.Max, "lte"))
+	case FieldEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		// oneof не подходит: варианты могут содержать пробелы
+		if !slices.Contains(f.Options, s) {
+			return nil, validator.FieldError(validator.ErrFieldNotAllowed, name)
+		}
+
+		return s, nil
+	case FieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		date, err := time.Parse(FieldDateLayout, s)
+		if err != nil {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		if f.MinDate != nil && date.Before(*f.MinDate) {
+			return nil, validator.FieldError(validator.ErrFieldBelowMinVal, name)
+		}
+
+		if f.MaxDate != nil && date.After(*f.MaxDate) {
+			return nil, validator.FieldError(validator.ErrFieldExceedsMaxVal, name)
+		}
+
+		return s, nil
+	case FieldBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, validator.FieldError(validator.ErrInvalidFormat, name)
+		}
+
+		return b, nil
+	}
+
+	return nil, validator.FieldError(validator.ErrUnknownValidation, name)
+}
+
+// boundsTag - теги validator для границ; пустая строка, если границ нет
+func boundsTag(min *float64, minTag string, max *float64, maxTag string) string {
+	tags := make([]string, 0, 2)
+	if min != nil {
+		tags = append(tags, minTag+"="+strconv.FormatFloat(*min, 'f', -1, 64))
+	}
+
+	if max != nil {
+		tags = append(tags, maxTag+"="+strconv.FormatFloat(*max, 'f', -1, 64))
+	}
+
+	return strings.Join(tags, ",")
+}
+
+// validateBounds - проверка границ тегами validator, тот же текст ошибок, что и у тела запроса
+func validateBounds(ctx context.Context, name string, value any, tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	return validator.ValidateVar(ctx, name, value, tag)
+}
+
+// fieldName - имя поля в тексте ошибки
+func fieldName(key string) string {
+	return "fields." + key
+}
+
+// fieldText - значение поля как строка для фильтра, так же его отдаёт оператор ->> в PostgreSQL
+func fieldText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return ""
+}
+
+// withoutField - значения полей задачи без key; исходная карта не меняется
+func withoutField(fields map[string]any, key string) map[string]any {
+	result := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if k != key {
+			result[k] = v
+		}
+	}
+
+	return result
+}
@@ -4,35 +4,56 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/volkowlad/week4/internal/myerr"
-)
-
-const (
-	statusNew      = "new"
-	statusProgress = "in_progress"
-	statusDone     = "done"
+	"github.com/volkowlad/week4/internal/workflow"
 )
 
 type repMemory struct {
-	Task sync.Map
-}
+	mu       sync.RWMutex // изменения задач, чтобы проверки иерархии и запись шли атомарно; чтение задач под RLock
+	Task     sync.Map
+	APIKeys  sync.Map
+	keyIds   sync.Map // хеш ключа -> id, чтобы проверка ключа не перебирала все ключи
+	tags     *tagIndex
+	children map[uuid.UUID]map[uuid.UUID]struct{} // родитель -> подзадачи, включая задачи в корзине; под mu
+
+	workflow *workflow.Workflow
 
-func NewMemory() Repository {
-	return &repMemory{}
+	blockers map[uuid.UUID]map[uuid.UUID]struct{} // задача -> блокирующие её задачи, под mu
+	comments map[uuid.UUID][]*Comment             // задача -> комментарии по порядку, под mu
+	files    map[uuid.UUID][]Attachment           // задача -> вложения по порядку, под mu
+	projects map[uuid.UUID]*Project               // под mu
+	history  map[uuid.UUID][]Revision             // задача -> ревизии по порядку, под mu
+	trash    map[uuid.UUID]*Task                  // задачи в корзине, в Task их нет; под mu
+	worklogs map[uuid.UUID][]*Worklog             // задача -> записи времени, под mu
+	timers   map[string]*Worklog                  // пользователь -> запущенный таймер, под mu
+	fields   map[uuid.UUID][]CustomField          // проект -> пользовательские поля по порядку, под mu
+
+	auditMu sync.Mutex
+	audit   []AuditEntry
 }
 
-func checkStatus(status string) string {
-	if status == statusProgress || status == statusDone {
-		return status
+func NewMemory(wf *workflow.Workflow) Repository {
+	return &repMemory{
+		tags:     newTagIndex(),
+		children: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		workflow: wf,
+		blockers: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		comments: make(map[uuid.UUID][]*Comment),
+		files:    make(map[uuid.UUID][]Attachment),
+		projects: make(map[uuid.UUID]*Project),
+		history:  make(map[uuid.UUID][]Revision),
+		trash:    make(map[uuid.UUID]*Task),
+		worklogs: make(map[uuid.UUID][]*Worklog),
+		timers:   make(map[string]*Worklog),
+		fields:   make(map[uuid.UUID][]CustomField),
 	}
-
-	return statusNew
 }
 
-func (r *repMemory) CreateTask(ctx context.Context, task TaskCreate) error {
+func (r *repMemory) CreateTask(ctx context.Context, owner string, task TaskCreate) error {
 	select {
 	case <-ctx.Done():
 		return errors.Wrap(ctx.Err(), "failed to insert task")
@@ -41,26 +62,65 @@ func (r *repMemory) CreateTask(ctx context.Context, task TaskCreate) error {
 			return errors.Wrap(myerr.ErrTitle, "failed to insert task")
 		}
 
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// id занят и задачей в корзине, и задачей другого владельца
+		if _, ok := r.Task.Load(task.Id); ok || r.trash[task.Id] != nil {
+			return errors.Wrap(myerr.ErrTaskExists, "failed to insert task")
+		}
+
+		if task.ParentId != nil {
+			if _, ok := r.ownedTask(owner, *task.ParentId); !ok {
+				return errors.Wrap(myerr.ErrParentNotFound, "failed to insert task")
+			}
+		}
+
+		projectId, err := r.taskProject(owner, task.ProjectId)
+		if err != nil {
+			return errors.Wrap(err, "failed to insert task")
+		}
+
+		fields, err := applyFields(ctx, r.fields[projectId], nil, task.Fields, true)
+		if err != nil {
+			return errors.Wrap(err, "failed to insert task")
+		}
+
 		newTask := &Task{
 			Id:          task.Id,
+			OwnerId:     owner,
 			Title:       task.Title,
 			Description: task.Description,
-			Status:      statusNew,
+			Status:      r.workflow.Initial(),
+			DueAt:       task.DueAt,
+			Tags:        NormalizeTags(task.Tags),
+			Assignees:   []string{},
+			ParentId:    task.ParentId,
+			ProjectId:   projectId,
+			Recurrence:  task.Recurrence,
+			Estimate:    task.Estimate,
+			Fields:      fields,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
 
 		r.Task.Store(newTask.Id, newTask)
+		r.setParent(newTask.Id, nil, newTask.ParentId)
+		r.tags.set(newTask.Id, nil, newTask.Tags)
+		r.addRevision(owner, newTask)
 
-		return nil
+		return r.appendAudit(owner, AuditCreate, newTask.Id, nil, newTask)
 	}
 }
 
-func (r *repMemory) GetTask(ctx context.Context, id uuid.UUID) (Task, error) {
+func (r *repMemory) GetTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
 	select {
 	case <-ctx.Done():
 		return Task{}, errors.Wrap(ctx.Err(), "failed to get task")
 	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
 		value, ok := r.Task.Load(id)
 		if !ok {
 			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to get task")
@@ -71,31 +131,58 @@ func (r *repMemory) GetTask(ctx context.Context, id uuid.UUID) (Task, error) {
 			return Task{}, errors.Wrap(myerr.ErrInvalidTaskType, "failed to get task")
 		}
 
+		if task.OwnerId != owner {
+			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to get task")
+		}
+
 		return *task, nil
 	}
 }
 
-func (r *repMemory) GetAllTasks(ctx context.Context, page, limit int) ([]Task, error) {
+func (r *repMemory) GetAllTasks(ctx context.Context, owner string, filter TaskFilter) ([]Task, error) {
 	select {
 	case <-ctx.Done():
 		return []Task{}, errors.Wrap(ctx.Err(), "failed to get all tasks")
 	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
 		var tasks []Task
 
-		r.Task.Range(func(key, value interface{}) bool {
+		collect := func(key, value interface{}) bool {
 			task, ok := value.(*Task)
 			if !ok {
 				return false
 			}
-			tasks = append(tasks, *task)
+			if task.OwnerId == owner && filter.matches(task, r.workflow) {
+				tasks = append(tasks, *task)
+			}
 
 			return true
-		})
+		}
 
-		if len(tasks) < 0 {
+		if filter.Tag != "" {
+			// по тегу обходим только задачи из индекса
+			for _, id := range r.tags.ids(filter.Tag) {
+				if value, ok := r.Task.Load(id); ok && !collect(id, value) {
+					break
+				}
+			}
+		} else {
+			r.Task.Range(collect)
+		}
+
+		// как и в PostgreSQL: пустая выборка - задач не найдено
+		if len(tasks) == 0 {
 			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to get all tasks")
 		}
 
+		// sync.Map не хранит порядок, сортируем для стабильной пагинации
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		})
+
+		page, limit := filter.Page, filter.Limit
 		start := (page - 1) * limit
 		if start > len(tasks) {
 			return []Task{}, errors.Wrap(myerr.ErrRange, "failed to get all tasks")
@@ -112,33 +199,105 @@ func (r *repMemory) GetAllTasks(ctx context.Context, page, limit int) ([]Task, e
 	}
 }
 
-func (r *repMemory) DeleteTask(ctx context.Context, id uuid.UUID) error {
+func (r *repMemory) DeleteTask(ctx context.Context, owner string, id uuid.UUID) error {
 	select {
 	case <-ctx.Done():
 		return errors.Wrap(ctx.Err(), "failed to delete task")
 	default:
-		if _, ok := r.Task.Load(id); ok {
-			r.Task.Delete(id)
-			return nil
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, id)
+		if !ok {
+			return errors.Wrap(myerr.ErrTaskNotFound, "failed to delete task")
 		}
 
-		return errors.Wrap(myerr.ErrTaskNotFound, "failed to delete task")
+		before := *task
+
+		// подзадачи, зависимости, комментарии, вложения и учёт времени остаются до окончательного удаления,
+		// запущенные таймеры останавливаются
+		now := time.Now()
+		task.DeletedAt = &now
+		task.UpdatedAt = now
+
+		r.Task.Delete(id)
+		r.tags.set(id, task.Tags, nil)
+		r.stopTaskTimers(id, now)
+		r.trash[id] = task
+		r.addRevision(owner, task)
+
+		return r.appendAudit(owner, AuditDelete, id, before, nil)
 	}
 }
 
-func (r *repMemory) UpdateTask(ctx context.Context, task UpdateTask, id uuid.UUID) (Task, error) {
+func (r *repMemory) UpdateTask(ctx context.Context, owner string, task UpdateTask, id uuid.UUID) (Task, error) {
 	select {
 	case <-ctx.Done():
 		return Task{}, errors.Wrap(ctx.Err(), "failed to update task")
 	default:
-		value, ok := r.Task.Load(id)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// загрузка под mu: иначе параллельный DeleteTask успеет перенести задачу в корзину
+		newTask, ok := r.ownedTask(owner, id)
 		if !ok {
 			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to update task")
 		}
 
-		newTask, ok := value.(*Task)
-		if !ok {
-			return Task{}, errors.Wrap(myerr.ErrInvalidTaskType, "failed to update task")
+		// поля задачи заменяются, а не меняются на месте, поэтому копия остаётся снимком до изменения
+		before := *newTask
+
+		if task.Status != "" {
+			if err := r.workflow.Check(newTask.Status, task.Status); err != nil {
+				return Task{}, errors.Wrap(err, "failed to update task")
+			}
+		}
+
+		if task.ParentId != nil {
+			if _, ok := r.ownedTask(owner, *task.ParentId); !ok {
+				return Task{}, errors.Wrap(myerr.ErrParentNotFound, "failed to update task")
+			}
+
+			if r.wouldCycle(owner, id, *task.ParentId) {
+				return Task{}, errors.Wrap(myerr.ErrTaskCycle, "failed to update task")
+			}
+		}
+
+		if r.workflow.IsDone(task.Status) && r.hasOpenChildren(owner, id) {
+			return Task{}, errors.Wrap(myerr.ErrOpenSubtasks, "failed to update task")
+		}
+
+		// заблокированную задачу можно только вернуть в начальный статус
+		if task.Status != "" && task.Status != r.workflow.Initial() && r.isBlocked(owner, id) {
+			return Task{}, errors.Wrap(myerr.ErrTaskBlocked, "failed to update task")
+		}
+
+		projectId := newTask.ProjectId
+		if task.ProjectId != nil {
+			var err error
+			if projectId, err = r.taskProject(owner, task.ProjectId); err != nil {
+				return Task{}, errors.Wrap(err, "failed to update task")
+			}
+		}
+
+		// значения проверяются до первого изменения задачи
+		fields := newTask.Fields
+		if task.Fields != nil || projectId != newTask.ProjectId {
+			var err error
+			if fields, err = applyFields(ctx, r.fields[projectId], newTask.Fields, task.Fields, true); err != nil {
+				return Task{}, errors.Wrap(err, "failed to update task")
+			}
+		}
+
+		newTask.ProjectId = projectId
+		newTask.Fields = fields
+
+		if task.ParentId != nil {
+			r.setParent(id, newTask.ParentId, task.ParentId)
+			newTask.ParentId = task.ParentId
+		} else if task.ClearParent {
+			r.setParent(id, newTask.ParentId, nil)
+			newTask.ParentId = nil
 		}
 
 		if task.Title != "" {
@@ -147,14 +306,114 @@ func (r *repMemory) UpdateTask(ctx context.Context, task UpdateTask, id uuid.UUI
 
 		if task.Description != "" {
 			newTask.Description = task.Description
+		} else if task.ClearDescription {
+			newTask.Description = ""
+		}
+
+		if task.Tags != nil {
+			tags := NormalizeTags(task.Tags)
+			r.tags.set(newTask.Id, newTask.Tags, tags)
+			newTask.Tags = tags
+		}
+
+		if task.DueAt != nil {
+			newTask.DueAt = task.DueAt
+		} else if task.ClearDueAt {
+			newTask.DueAt = nil
+		}
+
+		if task.Recurrence != nil {
+			newTask.Recurrence = task.Recurrence
+		} else if task.ClearRecurrence {
+			newTask.Recurrence = nil
+		}
+
+		if task.Points != nil {
+			newTask.Points = task.Points
+		} else if task.ClearPoints {
+			newTask.Points = nil
+		}
+
+		if task.Minutes != nil {
+			newTask.Minutes = task.Minutes
+		} else if task.ClearMinutes {
+			newTask.Minutes = nil
+		}
+
+		if task.Status != "" {
+			newTask.Status = task.Status
+
+			// время выполнения сохраняется при переходе между выполненными статусами
+			if !r.workflow.IsDone(task.Status) {
+				newTask.CompletedAt, newTask.ArchivedAt = nil, nil
+			} else if newTask.CompletedAt == nil {
+				now := time.Now()
+				newTask.CompletedAt = &now
+			}
 		}
 
-		status := checkStatus(task.Status)
-		newTask.Status = status
+		newTask.Overdue = newTask.Overdue && isOverdue(newTask, time.Now(), r.workflow)
 		newTask.UpdatedAt = time.Now()
 
+		// выполненное повторение порождает следующее, NextId не даёт создать его дважды
+		if r.workflow.IsDone(task.Status) {
+			if next, ok := nextOccurrence(*newTask, r.workflow.Initial()); ok {
+				next.CreatedAt = time.Now()
+				next.UpdatedAt = next.CreatedAt
+				r.Task.Store(next.Id, &next)
+				r.setParent(next.Id, nil, next.ParentId)
+				r.tags.set(next.Id, nil, next.Tags)
+				r.addRevision(owner, &next)
+
+				if err := r.appendAudit(owner, AuditCreate, next.Id, nil, next); err != nil {
+					return Task{}, err
+				}
+
+				recurrence := *newTask.Recurrence
+				recurrence.NextId = &next.Id
+				newTask.Recurrence = &recurrence
+			}
+		}
+
 		r.Task.Store(newTask.Id, newTask)
+		r.addRevision(owner, newTask)
+
+		if err := r.appendAudit(owner, AuditUpdate, id, before, newTask); err != nil {
+			return Task{}, err
+		}
 
 		return *newTask, nil
 	}
 }
+
+func (r *repMemory) FlagOverdueTasks(ctx context.Context, now time.Time) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to flag overdue tasks")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		flagged := make([]Task, 0)
+
+		var err error
+
+		r.Task.Range(func(key, value interface{}) bool {
+			task, ok := value.(*Task)
+			if !ok {
+				return true
+			}
+
+			if !task.Overdue && isOverdue(task, now, r.workflow) {
+				before := *task
+				task.Overdue = true
+				flagged = append(flagged, *task)
+				err = r.appendAudit(SystemActor, AuditUpdate, task.Id, before, task)
+			}
+
+			return err == nil
+		})
+
+		return flagged, err
+	}
+}
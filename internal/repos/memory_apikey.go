@@ -0,0 +1,116 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) CreateAPIKey(ctx context.Context, key APIKey) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to insert api key")
+	default:
+		key.CreatedAt = time.Now()
+		r.APIKeys.Store(key.Id, &key)
+		r.keyIds.Store(key.Hash, key.Id)
+
+		return nil
+	}
+}
+
+func (r *repMemory) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list api keys")
+	default:
+		keys := make([]APIKey, 0)
+
+		r.APIKeys.Range(func(_, value interface{}) bool {
+			if key, ok := value.(*APIKey); ok {
+				keys = append(keys, *key)
+			}
+
+			return true
+		})
+
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		})
+
+		return keys, nil
+	}
+}
+
+func (r *repMemory) GetAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return APIKey{}, errors.Wrap(ctx.Err(), "failed to get api key")
+	default:
+		id, ok := r.keyIds.Load(hash)
+		if !ok {
+			return APIKey{}, errors.Wrap(myerr.ErrAPIKeyNotFound, "failed to get api key")
+		}
+
+		value, ok := r.APIKeys.Load(id)
+		if !ok {
+			return APIKey{}, errors.Wrap(myerr.ErrAPIKeyNotFound, "failed to get api key")
+		}
+
+		key, ok := value.(*APIKey)
+		if !ok {
+			return APIKey{}, errors.Wrap(myerr.ErrInvalidTaskType, "failed to get api key")
+		}
+
+		return *key, nil
+	}
+}
+
+func (r *repMemory) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return r.updateAPIKey(ctx, id, "failed to revoke api key", func(key *APIKey) {
+		if key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+		}
+	})
+}
+
+func (r *repMemory) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	return r.updateAPIKey(ctx, id, "failed to touch api key", func(key *APIKey) {
+		now := time.Now()
+		key.LastUsedAt = &now
+	})
+}
+
+// updateAPIKey - ключи в памяти не изменяются на месте: меняем копию и подменяем указатель через CompareAndSwap
+func (r *repMemory) updateAPIKey(ctx context.Context, id uuid.UUID, msg string, apply func(key *APIKey)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), msg)
+		default:
+		}
+
+		value, ok := r.APIKeys.Load(id)
+		if !ok {
+			return errors.Wrap(myerr.ErrAPIKeyNotFound, msg)
+		}
+
+		key, ok := value.(*APIKey)
+		if !ok {
+			return errors.Wrap(myerr.ErrInvalidTaskType, msg)
+		}
+
+		updated := *key
+		apply(&updated)
+
+		if r.APIKeys.CompareAndSwap(id, key, &updated) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) ArchiveTasks(ctx context.Context, before time.Time) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to archive tasks")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		archived := make([]Task, 0)
+		now := time.Now()
+
+		var err error
+
+		r.Task.Range(func(key, value interface{}) bool {
+			task, ok := value.(*Task)
+			if !ok {
+				return true
+			}
+
+			if task.ArchivedAt == nil && task.CompletedAt != nil && task.CompletedAt.Before(before) &&
+				r.workflow.IsDone(task.Status) {
+				snapshot := *task
+				task.ArchivedAt = &now
+				task.UpdatedAt = now
+				r.addRevision(SystemActor, task)
+				archived = append(archived, *task)
+				err = r.appendAudit(SystemActor, AuditUpdate, task.Id, snapshot, task)
+			}
+
+			return err == nil
+		})
+
+		return archived, err
+	}
+}
+
+func (r *repMemory) UnarchiveTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
+	select {
+	case <-ctx.Done():
+		return Task{}, errors.Wrap(ctx.Err(), "failed to unarchive task")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, id)
+		if !ok {
+			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to unarchive task")
+		}
+
+		if task.ArchivedAt == nil {
+			return Task{}, errors.Wrap(myerr.ErrNotArchived, "failed to unarchive task")
+		}
+
+		before := *task
+
+		// срок до повторного архивирования отсчитывается заново
+		now := time.Now()
+		task.ArchivedAt = nil
+		task.CompletedAt = &now
+		task.UpdatedAt = now
+		r.addRevision(owner, task)
+
+		return *task, r.appendAudit(owner, AuditUpdate, id, before, task)
+	}
+}
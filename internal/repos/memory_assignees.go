@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) AssignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error) {
+	select {
+	case <-ctx.Done():
+		return Task{}, errors.Wrap(ctx.Err(), "failed to assign task")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, id)
+		if !ok {
+			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to assign task")
+		}
+
+		if containsString(task.Assignees, assignee) {
+			return *task, nil
+		}
+
+		before := *task
+
+		// новый срез, чтобы не менять уже отданные копии задачи
+		assignees := append(append(make([]string, 0, len(task.Assignees)+1), task.Assignees...), assignee)
+		sort.Strings(assignees)
+
+		task.Assignees = assignees
+		task.UpdatedAt = time.Now()
+		r.addRevision(owner, task)
+
+		return *task, r.appendAudit(owner, AuditUpdate, id, before, task)
+	}
+}
+
+func (r *repMemory) UnassignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error) {
+	select {
+	case <-ctx.Done():
+		return Task{}, errors.Wrap(ctx.Err(), "failed to unassign task")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, id)
+		if !ok {
+			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to unassign task")
+		}
+
+		if !containsString(task.Assignees, assignee) {
+			return Task{}, errors.Wrap(myerr.ErrNotAssigned, "failed to unassign task")
+		}
+
+		before := *task
+
+		assignees := make([]string, 0, len(task.Assignees)-1)
+		for _, a := range task.Assignees {
+			if a != assignee {
+				assignees = append(assignees, a)
+			}
+		}
+
+		task.Assignees = assignees
+		task.UpdatedAt = time.Now()
+		r.addRevision(owner, task)
+
+		return *task, r.appendAudit(owner, AuditUpdate, id, before, task)
+	}
+}
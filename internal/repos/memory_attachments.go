@@ -0,0 +1,98 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) AddAttachment(ctx context.Context, owner string, a Attachment) (Attachment, error) {
+	select {
+	case <-ctx.Done():
+		return Attachment{}, errors.Wrap(ctx.Err(), "failed to add attachment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, a.TaskId); !ok {
+			return Attachment{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to add attachment")
+		}
+
+		a.CreatedAt = time.Now()
+		r.files[a.TaskId] = append(r.files[a.TaskId], a)
+
+		return a, r.appendAudit(owner, AuditAttachmentAdd, a.TaskId, nil, a)
+	}
+}
+
+func (r *repMemory) ListAttachments(ctx context.Context, owner string, taskId uuid.UUID) ([]Attachment, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list attachments")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to list attachments")
+		}
+
+		return append([]Attachment{}, r.files[taskId]...), nil
+	}
+}
+
+func (r *repMemory) GetAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) (Attachment, error) {
+	select {
+	case <-ctx.Done():
+		return Attachment{}, errors.Wrap(ctx.Err(), "failed to get attachment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		i, err := r.attachmentIndex(owner, taskId, id)
+		if err != nil {
+			return Attachment{}, errors.Wrap(err, "failed to get attachment")
+		}
+
+		return r.files[taskId][i], nil
+	}
+}
+
+func (r *repMemory) DeleteAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to delete attachment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		i, err := r.attachmentIndex(owner, taskId, id)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete attachment")
+		}
+
+		files := r.files[taskId]
+		r.files[taskId] = append(files[:i:i], files[i+1:]...)
+
+		return r.appendAudit(owner, AuditAttachmentDelete, taskId, files[i], nil)
+	}
+}
+
+// attachmentIndex - позиция вложения у задачи владельца; вызывается под mu
+func (r *repMemory) attachmentIndex(owner string, taskId, id uuid.UUID) (int, error) {
+	if _, ok := r.ownedTask(owner, taskId); !ok {
+		return 0, myerr.ErrTaskNotFound
+	}
+
+	for i, a := range r.files[taskId] {
+		if a.Id == id {
+			return i, nil
+		}
+	}
+
+	return 0, myerr.ErrNoAttachment
+}
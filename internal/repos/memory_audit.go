@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// appendAudit - запись журнала вместе с изменением; вызывается под mu
+func (r *repMemory) appendAudit(actor, action string, taskId uuid.UUID, before, after any) error {
+	entry, err := newAuditEntry(actor, action, taskId, before, after)
+	if err != nil {
+		return errors.Wrap(err, "failed to append audit entry")
+	}
+
+	r.auditMu.Lock()
+	defer r.auditMu.Unlock()
+
+	if n := len(r.audit); n > 0 {
+		entry.PrevHash = r.audit[n-1].Hash
+	}
+
+	entry.Seq = int64(len(r.audit) + 1)
+	entry.CreatedAt = stampAudit()
+	entry.Hash = AuditHash(entry.PrevHash, entry)
+
+	r.audit = append(r.audit, entry)
+
+	return nil
+}
+
+func (r *repMemory) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list audit entries")
+	default:
+		r.auditMu.Lock()
+		defer r.auditMu.Unlock()
+
+		entries := make([]AuditEntry, 0)
+		for _, e := range r.audit {
+			if filter.matches(e) {
+				entries = append(entries, e)
+			}
+		}
+
+		start := (filter.Page - 1) * filter.Limit
+		if start > len(entries) {
+			return []AuditEntry{}, errors.Wrap(myerr.ErrRange, "failed to list audit entries")
+		}
+
+		end := start + filter.Limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		return entries[start:end], nil
+	}
+}
+
+func (r *repMemory) AuditChain(ctx context.Context) ([]AuditEntry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to read audit chain")
+	default:
+		r.auditMu.Lock()
+		defer r.auditMu.Unlock()
+
+		entries := make([]AuditEntry, len(r.audit))
+		copy(entries, r.audit)
+
+		return entries, nil
+	}
+}
@@ -0,0 +1,146 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) ListChecklist(ctx context.Context, owner string, taskId uuid.UUID) ([]ChecklistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list checklist")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, taskId)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to list checklist")
+		}
+
+		return append([]ChecklistItem{}, task.Checklist...), nil
+	}
+}
+
+func (r *repMemory) AddChecklistItem(ctx context.Context, owner string, taskId uuid.UUID, item ChecklistItem) ([]ChecklistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to add checklist item")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, taskId)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to add checklist item")
+		}
+
+		item.Position = len(task.Checklist) + 1
+		items := append(append(make([]ChecklistItem, 0, len(task.Checklist)+1), task.Checklist...), item)
+
+		return r.setChecklist(owner, task, items)
+	}
+}
+
+func (r *repMemory) MoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, position int) ([]ChecklistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to move checklist item")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, taskId)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to move checklist item")
+		}
+
+		items, ok := moveItem(task.Checklist, id, position)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrNoChecklistItem, "failed to move checklist item")
+		}
+
+		return r.setChecklist(owner, task, items)
+	}
+}
+
+func (r *repMemory) ToggleChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, done *bool) ([]ChecklistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to toggle checklist item")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, taskId)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to toggle checklist item")
+		}
+
+		i := itemIndex(task.Checklist, id)
+		if i < 0 {
+			return nil, errors.Wrap(myerr.ErrNoChecklistItem, "failed to toggle checklist item")
+		}
+
+		items := append([]ChecklistItem{}, task.Checklist...)
+		if done != nil {
+			items[i].Done = *done
+		} else {
+			items[i].Done = !items[i].Done
+		}
+
+		return r.setChecklist(owner, task, items)
+	}
+}
+
+func (r *repMemory) RemoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID) ([]ChecklistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to remove checklist item")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.ownedTask(owner, taskId)
+		if !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to remove checklist item")
+		}
+
+		i := itemIndex(task.Checklist, id)
+		if i < 0 {
+			return nil, errors.Wrap(myerr.ErrNoChecklistItem, "failed to remove checklist item")
+		}
+
+		items := append(append(make([]ChecklistItem, 0, len(task.Checklist)-1), task.Checklist[:i]...), task.Checklist[i+1:]...)
+
+		return r.setChecklist(owner, task, renumber(items))
+	}
+}
+
+// setChecklist - замена чек-листа задачи новым срезом, чтобы не менять уже отданные копии задачи; под mu.
+// Ревизию пункты чек-листа не создают: в снимке задачи их нет, в журнал аудита пишется чек-лист целиком
+func (r *repMemory) setChecklist(owner string, task *Task, items []ChecklistItem) ([]ChecklistItem, error) {
+	before := task.Checklist
+
+	task.Checklist = items
+	task.Progress = progressOf(items)
+	task.UpdatedAt = time.Now()
+
+	return append([]ChecklistItem{}, items...), r.appendAudit(owner, AuditChecklist, task.Id, before, items)
+}
+
+// itemIndex - индекс пункта в чек-листе, -1 если его нет
+func itemIndex(items []ChecklistItem, id uuid.UUID) int {
+	for i, item := range items {
+		if item.Id == id {
+			return i
+		}
+	}
+
+	return -1
+}
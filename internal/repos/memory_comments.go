@@ -0,0 +1,135 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) AddComment(ctx context.Context, owner string, comment Comment) (Comment, error) {
+	select {
+	case <-ctx.Done():
+		return Comment{}, errors.Wrap(ctx.Err(), "failed to add comment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, comment.TaskId); !ok {
+			return Comment{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to add comment")
+		}
+
+		comment.CreatedAt = time.Now()
+		comment.EditedAt = nil
+
+		stored := comment
+		r.comments[comment.TaskId] = append(r.comments[comment.TaskId], &stored)
+
+		return comment, r.appendAudit(owner, AuditCommentAdd, comment.TaskId, nil, comment)
+	}
+}
+
+func (r *repMemory) ListComments(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Comment, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list comments")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to list comments")
+		}
+
+		all := r.comments[taskId]
+
+		start := (page - 1) * limit
+		if page > 1 && start >= len(all) {
+			return []Comment{}, errors.Wrap(myerr.ErrRange, "failed to list comments")
+		}
+
+		end := start + limit
+		if end > len(all) {
+			end = len(all)
+		}
+
+		comments := make([]Comment, 0, end-start)
+		for _, c := range all[start:end] {
+			comments = append(comments, *c)
+		}
+
+		return comments, nil
+	}
+}
+
+func (r *repMemory) UpdateComment(ctx context.Context, owner string, taskId, id uuid.UUID, body string) (Comment, error) {
+	select {
+	case <-ctx.Done():
+		return Comment{}, errors.Wrap(ctx.Err(), "failed to update comment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		comment, err := r.ownedComment(owner, taskId, id)
+		if err != nil {
+			return Comment{}, errors.Wrap(err, "failed to update comment")
+		}
+
+		before := *comment
+
+		now := time.Now()
+		comment.Body = body
+		comment.EditedAt = &now
+
+		return *comment, r.appendAudit(owner, AuditCommentUpdate, taskId, before, comment)
+	}
+}
+
+func (r *repMemory) DeleteComment(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to delete comment")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		comment, err := r.ownedComment(owner, taskId, id)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete comment")
+		}
+
+		comments := r.comments[taskId]
+		for i, c := range comments {
+			if c.Id == id {
+				r.comments[taskId] = append(comments[:i:i], comments[i+1:]...)
+				break
+			}
+		}
+
+		return r.appendAudit(owner, AuditCommentDelete, taskId, comment, nil)
+	}
+}
+
+// ownedComment - комментарий к задаче владельца, который может менять только автор; вызывается под mu
+func (r *repMemory) ownedComment(owner string, taskId, id uuid.UUID) (*Comment, error) {
+	if _, ok := r.ownedTask(owner, taskId); !ok {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	for _, c := range r.comments[taskId] {
+		if c.Id != id {
+			continue
+		}
+
+		if c.Author != owner {
+			return nil, myerr.ErrCommentAuthor
+		}
+
+		return c, nil
+	}
+
+	return nil, myerr.ErrCommentNotFound
+}
@@ -0,0 +1,186 @@
+package repos
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) AddDependency(ctx context.Context, owner string, dep Dependency) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to add dependency")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, dep.TaskId); !ok {
+			return errors.Wrap(myerr.ErrTaskNotFound, "failed to add dependency")
+		}
+
+		if _, ok := r.ownedTask(owner, dep.BlockerId); !ok {
+			return errors.Wrap(myerr.ErrTaskNotFound, "failed to add dependency")
+		}
+
+		if r.dependsOn(dep.BlockerId, dep.TaskId) {
+			return errors.Wrap(myerr.ErrDependencyCycle, "failed to add dependency")
+		}
+
+		blockers, ok := r.blockers[dep.TaskId]
+		if !ok {
+			blockers = make(map[uuid.UUID]struct{})
+			r.blockers[dep.TaskId] = blockers
+		}
+
+		// повторное добавление ничего не меняет
+		if _, ok = blockers[dep.BlockerId]; ok {
+			return nil
+		}
+		blockers[dep.BlockerId] = struct{}{}
+
+		return r.appendAudit(owner, AuditDependencyAdd, dep.TaskId, nil, dep)
+	}
+}
+
+func (r *repMemory) RemoveDependency(ctx context.Context, owner string, dep Dependency) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to remove dependency")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, dep.TaskId); !ok {
+			return errors.Wrap(myerr.ErrTaskNotFound, "failed to remove dependency")
+		}
+
+		if _, ok := r.blockers[dep.TaskId][dep.BlockerId]; !ok {
+			return errors.Wrap(myerr.ErrNoDependency, "failed to remove dependency")
+		}
+
+		delete(r.blockers[dep.TaskId], dep.BlockerId)
+		if len(r.blockers[dep.TaskId]) == 0 {
+			delete(r.blockers, dep.TaskId)
+		}
+
+		return r.appendAudit(owner, AuditDependencyRemove, dep.TaskId, dep, nil)
+	}
+}
+
+func (r *repMemory) GetBlockers(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to get blockers")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, id); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to get blockers")
+		}
+
+		tasks := make([]Task, 0, len(r.blockers[id]))
+		for blocker := range r.blockers[id] {
+			if task, ok := r.ownedTask(owner, blocker); ok {
+				tasks = append(tasks, *task)
+			}
+		}
+
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		})
+
+		return tasks, nil
+	}
+}
+
+func (r *repMemory) DependencyGraph(ctx context.Context, owner string) ([]Task, []Dependency, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, errors.Wrap(ctx.Err(), "failed to get dependency graph")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		tasks := make([]Task, 0)
+		deps := make([]Dependency, 0)
+
+		r.Task.Range(func(_, value interface{}) bool {
+			task, ok := value.(*Task)
+			if !ok || task.OwnerId != owner {
+				return true
+			}
+
+			tasks = append(tasks, *task)
+			for blocker := range r.blockers[task.Id] {
+				// задачи в корзине в граф не попадают
+				if _, ok := r.ownedTask(owner, blocker); ok {
+					deps = append(deps, Dependency{TaskId: task.Id, BlockerId: blocker})
+				}
+			}
+
+			return true
+		})
+
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		})
+
+		return tasks, deps, nil
+	}
+}
+
+// dependsOn - зависит ли from от to (напрямую или через другие задачи); вызывается под mu
+func (r *repMemory) dependsOn(from, to uuid.UUID) bool {
+	if from == to {
+		return true
+	}
+
+	seen := map[uuid.UUID]bool{from: true}
+	stack := []uuid.UUID{from}
+
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for blocker := range r.blockers[cur] {
+			if blocker == to {
+				return true
+			}
+
+			if !seen[blocker] {
+				seen[blocker] = true
+				stack = append(stack, blocker)
+			}
+		}
+	}
+
+	return false
+}
+
+// isBlocked - есть ли невыполненные блокирующие задачи; вызывается под mu
+func (r *repMemory) isBlocked(owner string, id uuid.UUID) bool {
+	for blocker := range r.blockers[id] {
+		if task, ok := r.ownedTask(owner, blocker); ok && !r.workflow.IsDone(task.Status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropDependencies - удаление всех рёбер с задачей, как ON DELETE CASCADE; вызывается под mu
+func (r *repMemory) dropDependencies(id uuid.UUID) {
+	delete(r.blockers, id)
+
+	for task, blockers := range r.blockers {
+		delete(blockers, id)
+		if len(blockers) == 0 {
+			delete(r.blockers, task)
+		}
+	}
+}
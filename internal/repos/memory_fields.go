@@ -0,0 +1,151 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) CreateField(ctx context.Context, owner string, field CustomField) (CustomField, error) {
+	select {
+	case <-ctx.Done():
+		return CustomField{}, errors.Wrap(ctx.Err(), "failed to create field")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedProject(owner, field.ProjectId); !ok {
+			return CustomField{}, errors.Wrap(myerr.ErrProjectNotFound, "failed to create field")
+		}
+
+		if fieldIndex(r.fields[field.ProjectId], field.Key) >= 0 {
+			return CustomField{}, errors.Wrap(myerr.ErrFieldKeyTaken, "failed to create field")
+		}
+
+		field.CreatedAt = time.Now()
+		field.UpdatedAt = field.CreatedAt
+
+		// новый срез, чтобы не менять уже отданную схему
+		fields := r.fields[field.ProjectId]
+		r.fields[field.ProjectId] = append(append(make([]CustomField, 0, len(fields)+1), fields...), field)
+
+		return field, nil
+	}
+}
+
+func (r *repMemory) ListFields(ctx context.Context, owner string, projectId uuid.UUID) ([]CustomField, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list fields")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedProject(owner, projectId); !ok {
+			return nil, errors.Wrap(myerr.ErrProjectNotFound, "failed to list fields")
+		}
+
+		return append([]CustomField{}, r.fields[projectId]...), nil
+	}
+}
+
+func (r *repMemory) UpdateField(ctx context.Context, owner string, projectId uuid.UUID, key string, field CustomField) (CustomField, error) {
+	select {
+	case <-ctx.Done():
+		return CustomField{}, errors.Wrap(ctx.Err(), "failed to update field")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedProject(owner, projectId); !ok {
+			return CustomField{}, errors.Wrap(myerr.ErrProjectNotFound, "failed to update field")
+		}
+
+		i := fieldIndex(r.fields[projectId], key)
+		if i < 0 {
+			return CustomField{}, errors.Wrap(myerr.ErrFieldNotFound, "failed to update field")
+		}
+
+		fields := append([]CustomField{}, r.fields[projectId]...)
+		stored := fields[i]
+
+		stored.Name = field.Name
+		stored.Required = field.Required
+		stored.Min, stored.Max = field.Min, field.Max
+		stored.MinDate, stored.MaxDate = field.MinDate, field.MaxDate
+		stored.Options = field.Options
+		stored.UpdatedAt = time.Now()
+
+		fields[i] = stored
+		r.fields[projectId] = fields
+
+		return stored, nil
+	}
+}
+
+func (r *repMemory) DeleteField(ctx context.Context, owner string, projectId uuid.UUID, key string) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to delete field")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedProject(owner, projectId); !ok {
+			return errors.Wrap(myerr.ErrProjectNotFound, "failed to delete field")
+		}
+
+		fields := r.fields[projectId]
+		i := fieldIndex(fields, key)
+		if i < 0 {
+			return errors.Wrap(myerr.ErrFieldNotFound, "failed to delete field")
+		}
+
+		r.fields[projectId] = append(append(make([]CustomField, 0, len(fields)-1), fields[:i]...), fields[i+1:]...)
+
+		// значения снимаются и с задач в корзине, чтобы после восстановления не появилось поле без схемы
+		unset := func(task *Task) error {
+			if _, ok := task.Fields[key]; !ok || task.ProjectId != projectId {
+				return nil
+			}
+
+			before := *task
+			task.Fields = withoutField(task.Fields, key)
+
+			return r.appendAudit(owner, AuditUpdate, task.Id, before, task)
+		}
+
+		var err error
+
+		r.Task.Range(func(_, value interface{}) bool {
+			if task, ok := value.(*Task); ok {
+				err = unset(task)
+			}
+
+			return err == nil
+		})
+
+		for _, task := range r.trash {
+			if err == nil {
+				err = unset(task)
+			}
+		}
+
+		return err
+	}
+}
+
+// fieldIndex - индекс поля с ключом, -1 если его нет
+func fieldIndex(fields []CustomField, key string) int {
+	for i, field := range fields {
+		if field.Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
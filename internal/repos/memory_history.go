@@ -0,0 +1,71 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// addRevision - снимок задачи после изменения; вызывается под mu.
+// Срезы и указатели задачи при изменениях заменяются, а не правятся, поэтому копии достаточно.
+func (r *repMemory) addRevision(owner string, task *Task) {
+	r.history[task.Id] = append(r.history[task.Id], Revision{
+		TaskId:    task.Id,
+		Rev:       len(r.history[task.Id]) + 1,
+		Actor:     owner,
+		Task:      *task,
+		CreatedAt: task.UpdatedAt,
+	})
+}
+
+func (r *repMemory) ListRevisions(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Revision, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list revisions")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to list revisions")
+		}
+
+		all := r.history[taskId]
+
+		start := (page - 1) * limit
+		if page > 1 && start >= len(all) {
+			return []Revision{}, errors.Wrap(myerr.ErrRange, "failed to list revisions")
+		}
+
+		end := start + limit
+		if end > len(all) {
+			end = len(all)
+		}
+
+		return append([]Revision{}, all[start:end]...), nil
+	}
+}
+
+func (r *repMemory) GetRevision(ctx context.Context, owner string, taskId uuid.UUID, rev int) (Revision, error) {
+	select {
+	case <-ctx.Done():
+		return Revision{}, errors.Wrap(ctx.Err(), "failed to get revision")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return Revision{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to get revision")
+		}
+
+		all := r.history[taskId]
+		if rev < 1 || rev > len(all) {
+			return Revision{}, errors.Wrap(myerr.ErrNoRevision, "failed to get revision")
+		}
+
+		return all[rev-1], nil
+	}
+}
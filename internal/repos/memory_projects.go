@@ -0,0 +1,216 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) CreateProject(ctx context.Context, owner string, project Project) (Project, error) {
+	select {
+	case <-ctx.Done():
+		return Project{}, errors.Wrap(ctx.Err(), "failed to create project")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.projectByKey(owner, project.Key) != nil {
+			return Project{}, errors.Wrap(myerr.ErrProjectKeyTaken, "failed to create project")
+		}
+
+		project.OwnerId = owner
+		project.CreatedAt = time.Now()
+		project.UpdatedAt = project.CreatedAt
+
+		stored := project
+		r.projects[project.Id] = &stored
+
+		return project, nil
+	}
+}
+
+func (r *repMemory) GetProject(ctx context.Context, owner string, id uuid.UUID) (Project, error) {
+	select {
+	case <-ctx.Done():
+		return Project{}, errors.Wrap(ctx.Err(), "failed to get project")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		project, ok := r.ownedProject(owner, id)
+		if !ok {
+			return Project{}, errors.Wrap(myerr.ErrProjectNotFound, "failed to get project")
+		}
+
+		return *project, nil
+	}
+}
+
+func (r *repMemory) ListProjects(ctx context.Context, owner string, filter ProjectFilter) ([]Project, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list projects")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		projects := make([]Project, 0)
+		for _, p := range r.projects {
+			if p.OwnerId == owner && (filter.Archived == nil || p.Archived == *filter.Archived) {
+				projects = append(projects, *p)
+			}
+		}
+
+		sort.Slice(projects, func(i, j int) bool {
+			return projects[i].CreatedAt.Before(projects[j].CreatedAt)
+		})
+
+		start := (filter.Page - 1) * filter.Limit
+		if filter.Page > 1 && start >= len(projects) {
+			return []Project{}, errors.Wrap(myerr.ErrRange, "failed to list projects")
+		}
+
+		end := start + filter.Limit
+		if end > len(projects) {
+			end = len(projects)
+		}
+
+		return projects[start:end], nil
+	}
+}
+
+func (r *repMemory) UpdateProject(ctx context.Context, owner string, id uuid.UUID, update UpdateProject) (Project, error) {
+	select {
+	case <-ctx.Done():
+		return Project{}, errors.Wrap(ctx.Err(), "failed to update project")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		project, ok := r.ownedProject(owner, id)
+		if !ok {
+			return Project{}, errors.Wrap(myerr.ErrProjectNotFound, "failed to update project")
+		}
+
+		if update.Key != "" && update.Key != project.Key {
+			if r.projectByKey(owner, update.Key) != nil {
+				return Project{}, errors.Wrap(myerr.ErrProjectKeyTaken, "failed to update project")
+			}
+
+			project.Key = update.Key
+		}
+
+		if update.Name != "" {
+			project.Name = update.Name
+		}
+
+		if update.Description != "" {
+			project.Description = update.Description
+		}
+
+		if update.Archived != nil {
+			project.Archived = *update.Archived
+		}
+
+		project.UpdatedAt = time.Now()
+
+		return *project, nil
+	}
+}
+
+func (r *repMemory) DeleteProject(ctx context.Context, owner string, id uuid.UUID) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to delete project")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedProject(owner, id); !ok {
+			return errors.Wrap(myerr.ErrProjectNotFound, "failed to delete project")
+		}
+
+		empty := true
+		r.Task.Range(func(_, value interface{}) bool {
+			if task, ok := value.(*Task); ok && task.ProjectId == id {
+				empty = false
+			}
+
+			return empty
+		})
+
+		// задачи из корзины можно восстановить, проект им нужен
+		for _, task := range r.trash {
+			if task.ProjectId == id {
+				empty = false
+			}
+		}
+
+		if !empty {
+			return errors.Wrap(myerr.ErrProjectNotEmpty, "failed to delete project")
+		}
+
+		delete(r.projects, id)
+		delete(r.fields, id)
+
+		return nil
+	}
+}
+
+// ownedProject - проект, если он принадлежит владельцу; вызывается под mu
+func (r *repMemory) ownedProject(owner string, id uuid.UUID) (*Project, bool) {
+	project, ok := r.projects[id]
+	if !ok || project.OwnerId != owner {
+		return nil, false
+	}
+
+	return project, true
+}
+
+// projectByKey - проект владельца с ключом; вызывается под mu
+func (r *repMemory) projectByKey(owner, key string) *Project {
+	for _, p := range r.projects {
+		if p.OwnerId == owner && p.Key == key {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// taskProject - проект для новой или переносимой задачи: указанный или проект по умолчанию; вызывается под mu
+func (r *repMemory) taskProject(owner string, id *uuid.UUID) (uuid.UUID, error) {
+	var project *Project
+
+	if id == nil {
+		project = r.projectByKey(owner, DefaultProjectKey)
+		if project == nil {
+			now := time.Now()
+			project = &Project{
+				Id:        uuid.New(),
+				OwnerId:   owner,
+				Key:       DefaultProjectKey,
+				Name:      defaultProjectName,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			r.projects[project.Id] = project
+		}
+	} else {
+		var ok bool
+		if project, ok = r.ownedProject(owner, *id); !ok {
+			return uuid.Nil, myerr.ErrProjectNotFound
+		}
+	}
+
+	if project.Archived {
+		return uuid.Nil, myerr.ErrProjectArchived
+	}
+
+	return project.Id, nil
+}
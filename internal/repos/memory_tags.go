@@ -0,0 +1,99 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// tagIndex - индекс тег -> задачи для хранилища в памяти
+type tagIndex struct {
+	mu    sync.RWMutex
+	tasks map[string]map[uuid.UUID]struct{}
+}
+
+func newTagIndex() *tagIndex {
+	return &tagIndex{tasks: make(map[string]map[uuid.UUID]struct{})}
+}
+
+// set - замена тегов задачи: old - прежние теги, tags - новые
+func (idx *tagIndex) set(id uuid.UUID, old, tags []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, tag := range old {
+		if ids, ok := idx.tasks[tag]; ok {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(idx.tasks, tag)
+			}
+		}
+	}
+
+	for _, tag := range tags {
+		ids, ok := idx.tasks[tag]
+		if !ok {
+			ids = make(map[uuid.UUID]struct{})
+			idx.tasks[tag] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// ids - задачи с тегом
+func (idx *tagIndex) ids(tag string) []uuid.UUID {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	ids := make([]uuid.UUID, 0, len(idx.tasks[tag]))
+	for id := range idx.tasks[tag] {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func (r *repMemory) TagCounts(ctx context.Context, owner string) ([]TagCount, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to count tags")
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		counts := make(map[string]int)
+
+		r.Task.Range(func(_, value interface{}) bool {
+			if task, ok := value.(*Task); ok && task.OwnerId == owner {
+				for _, tag := range task.Tags {
+					counts[tag]++
+				}
+			}
+
+			return true
+		})
+
+		return sortTagCounts(counts), nil
+	}
+}
+
+// sortTagCounts - сначала самые используемые теги
+func sortTagCounts(counts map[string]int) []TagCount {
+	result := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		result = append(result, TagCount{Tag: tag, Count: n})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+
+		return result[i].Tag < result[j].Tag
+	})
+
+	return result
+}
@@ -0,0 +1,202 @@
+package repos
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestMemoryConcurrentAccess - запускать с -race: чтение, изменение и удаление задачи параллельно
+func TestMemoryConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	rep := backends(t)["memory"].(*repMemory)
+
+	parent, id := uuid.New(), uuid.New()
+	if err := rep.CreateTask(ctx, alice, TaskCreate{Id: parent, Title: "parent"}); err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+
+	if err := rep.CreateTask(ctx, alice, TaskCreate{Id: id, Title: "task", ParentId: &parent}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			_, _ = rep.UpdateTask(ctx, alice, UpdateTask{Title: "updated", Tags: []string{"x"}}, id)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = rep.GetTask(ctx, alice, id)
+			_, _ = rep.GetAllTasks(ctx, alice, TaskFilter{Page: 1, Limit: 10})
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = rep.GetDescendants(ctx, alice, parent)
+			_, _ = rep.TagCounts(ctx, alice)
+		}()
+		go func() {
+			defer wg.Done()
+			if i == 25 {
+				_ = rep.DeleteTask(ctx, alice, id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// задача не может быть одновременно живой и в корзине
+	if _, live := rep.Task.Load(id); live || rep.trash[id] == nil {
+		t.Fatalf("task state after delete: live %v, trashed %v", live, rep.trash[id] != nil)
+	}
+
+	if children, err := rep.GetChildren(ctx, alice, parent); err != nil || len(children) != 0 {
+		t.Fatalf("children of parent: %v, %v", children, err)
+	}
+}
+
+func TestMemoryChildrenIndex(t *testing.T) {
+	ctx := context.Background()
+	rep := backends(t)["memory"].(*repMemory)
+
+	a, b, child := uuid.New(), uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{a, b} {
+		if err := rep.CreateTask(ctx, alice, TaskCreate{Id: id, Title: "parent"}); err != nil {
+			t.Fatalf("create parent: %v", err)
+		}
+	}
+
+	if err := rep.CreateTask(ctx, alice, TaskCreate{Id: child, Title: "child", ParentId: &a}); err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+
+	count := func(parent uuid.UUID) int {
+		children, err := rep.GetChildren(ctx, alice, parent)
+		if err != nil {
+			t.Fatalf("get children: %v", err)
+		}
+		return len(children)
+	}
+
+	if count(a) != 1 || count(b) != 0 {
+		t.Fatalf("after create: %d, %d", count(a), count(b))
+	}
+
+	if _, err := rep.UpdateTask(ctx, alice, UpdateTask{ParentId: &b}, child); err != nil {
+		t.Fatalf("move child: %v", err)
+	}
+
+	if count(a) != 0 || count(b) != 1 {
+		t.Fatalf("after move: %d, %d", count(a), count(b))
+	}
+
+	// подзадача в корзине не видна, после восстановления возвращается
+	if err := rep.DeleteTask(ctx, alice, child); err != nil {
+		t.Fatalf("delete child: %v", err)
+	}
+
+	if count(b) != 0 {
+		t.Fatalf("trashed child listed")
+	}
+
+	if _, err := rep.RestoreTask(ctx, alice, child); err != nil {
+		t.Fatalf("restore child: %v", err)
+	}
+
+	if count(b) != 1 {
+		t.Fatalf("restored child missing")
+	}
+
+	// после окончательного удаления родителя подзадача становится корневой
+	if err := rep.DeleteTask(ctx, alice, b); err != nil {
+		t.Fatalf("delete parent: %v", err)
+	}
+
+	if _, _, err := rep.PurgeTask(ctx, alice, b); err != nil {
+		t.Fatalf("purge parent: %v", err)
+	}
+
+	if _, ok := rep.children[b]; ok {
+		t.Fatalf("purged parent left in index")
+	}
+
+	task, err := rep.GetTask(ctx, alice, child)
+	if err != nil || task.ParentId != nil {
+		t.Fatalf("child after purge: %+v, %v", task, err)
+	}
+}
+
+func TestMemoryPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	rep := backends(t)["memory"].(*repMemory)
+
+	parent, child, prev, next, fresh := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, task := range []TaskCreate{
+		{Id: parent, Title: "parent"},
+		{Id: child, Title: "child", ParentId: &parent},
+		{Id: prev, Title: "previous occurrence"},
+		{Id: next, Title: "next occurrence"},
+		{Id: fresh, Title: "recently deleted"},
+	} {
+		if err := rep.CreateTask(ctx, alice, task); err != nil {
+			t.Fatalf("create %s: %v", task.Title, err)
+		}
+	}
+
+	value, _ := rep.Task.Load(prev)
+	value.(*Task).Recurrence = &Recurrence{Rule: "FREQ=DAILY", TZ: "UTC", Seq: 1, NextId: &next}
+
+	for _, id := range []uuid.UUID{next, parent, fresh} {
+		if err := rep.DeleteTask(ctx, alice, id); err != nil {
+			t.Fatalf("delete task: %v", err)
+		}
+	}
+
+	// next удалена раньше parent: в журнал они попадают по времени удаления
+	now := time.Now()
+	for id, age := range map[uuid.UUID]time.Duration{next: 48 * time.Hour, parent: 24 * time.Hour} {
+		deleted := now.Add(-age)
+		rep.trash[id].DeletedAt = &deleted
+	}
+
+	tasks, _, err := rep.PurgeTrash(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("purge trash: %v", err)
+	}
+
+	if len(tasks) != 2 || tasks[0].Id != next || tasks[1].Id != parent {
+		t.Fatalf("purged %+v, want next and parent", tasks)
+	}
+
+	if _, ok := rep.trash[fresh]; !ok || len(rep.trash) != 1 {
+		t.Fatalf("trash after purge: %v", rep.trash)
+	}
+
+	if task, err := rep.GetTask(ctx, alice, child); err != nil || task.ParentId != nil {
+		t.Fatalf("child after purge: %+v, %v", task, err)
+	}
+
+	if task, err := rep.GetTask(ctx, alice, prev); err != nil || task.Recurrence == nil || task.Recurrence.NextId != nil {
+		t.Fatalf("previous occurrence after purge: %+v, %v", task.Recurrence, err)
+	}
+
+	entries, err := rep.AuditChain(ctx)
+	if err != nil {
+		t.Fatalf("audit chain: %v", err)
+	}
+
+	purged := make([]uuid.UUID, 0)
+	for _, e := range entries {
+		if e.Action == AuditPurge {
+			purged = append(purged, e.TaskId)
+		}
+	}
+
+	if len(purged) != 2 || purged[0] != next || purged[1] != parent {
+		t.Fatalf("purge entries %v, want next then parent", purged)
+	}
+}
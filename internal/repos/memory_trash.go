@@ -0,0 +1,175 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) ListTrash(ctx context.Context, owner string, page, limit int) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to list trash")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		all := make([]Task, 0)
+		for _, task := range r.trash {
+			if task.OwnerId == owner {
+				all = append(all, *task)
+			}
+		}
+
+		sort.Slice(all, func(i, j int) bool {
+			if !all[i].DeletedAt.Equal(*all[j].DeletedAt) {
+				return all[i].DeletedAt.After(*all[j].DeletedAt)
+			}
+
+			return all[i].Id.String() < all[j].Id.String()
+		})
+
+		start := (page - 1) * limit
+		if page > 1 && start >= len(all) {
+			return []Task{}, errors.Wrap(myerr.ErrRange, "failed to list trash")
+		}
+
+		end := start + limit
+		if end > len(all) {
+			end = len(all)
+		}
+
+		return all[start:end], nil
+	}
+}
+
+func (r *repMemory) RestoreTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
+	select {
+	case <-ctx.Done():
+		return Task{}, errors.Wrap(ctx.Err(), "failed to restore task")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.trash[id]
+		if !ok || task.OwnerId != owner {
+			return Task{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to restore task")
+		}
+
+		delete(r.trash, id)
+		task.DeletedAt = nil
+		task.UpdatedAt = time.Now()
+
+		r.Task.Store(id, task)
+		r.tags.set(id, nil, task.Tags)
+		r.addRevision(owner, task)
+
+		return *task, r.appendAudit(owner, AuditRestore, id, nil, task)
+	}
+}
+
+func (r *repMemory) PurgeTask(ctx context.Context, owner string, id uuid.UUID) (Task, []Attachment, error) {
+	select {
+	case <-ctx.Done():
+		return Task{}, nil, errors.Wrap(ctx.Err(), "failed to purge task")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		task, ok := r.trash[id]
+		if !ok || task.OwnerId != owner {
+			return Task{}, nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to purge task")
+		}
+
+		purged := *task
+		files := r.purge(id)
+
+		return purged, files, r.appendAudit(owner, AuditPurge, id, purged, nil)
+	}
+}
+
+func (r *repMemory) PurgeTrash(ctx context.Context, before time.Time) ([]Task, []Attachment, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, errors.Wrap(ctx.Err(), "failed to purge trash")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		expired := make([]Task, 0)
+		for _, task := range r.trash {
+			if task.DeletedAt.Before(before) {
+				expired = append(expired, *task)
+			}
+		}
+
+		// порядок как в PostgreSQL, чтобы цепочка журнала не зависела от обхода map
+		sort.Slice(expired, func(i, j int) bool {
+			if !expired[i].DeletedAt.Equal(*expired[j].DeletedAt) {
+				return expired[i].DeletedAt.Before(*expired[j].DeletedAt)
+			}
+
+			return expired[i].Id.String() < expired[j].Id.String()
+		})
+
+		tasks := make([]Task, 0, len(expired))
+		files := make([]Attachment, 0)
+
+		for _, task := range expired {
+			tasks = append(tasks, task)
+			files = append(files, r.purge(task.Id)...)
+
+			if err := r.appendAudit(SystemActor, AuditPurge, task.Id, task, nil); err != nil {
+				return tasks, files, err
+			}
+		}
+
+		return tasks, files, nil
+	}
+}
+
+// purge - окончательное удаление задачи из корзины; вызывается под mu.
+// Ссылки на задачу обнуляются, как ON DELETE SET NULL в PostgreSQL.
+func (r *repMemory) purge(id uuid.UUID) []Attachment {
+	files := r.files[id]
+
+	r.setParent(id, r.trash[id].ParentId, nil)
+	delete(r.children, id)
+	delete(r.trash, id)
+	r.dropDependencies(id)
+	delete(r.comments, id)
+	delete(r.files, id)
+	delete(r.history, id)
+	delete(r.worklogs, id)
+
+	forget := func(task *Task) {
+		if task.ParentId != nil && *task.ParentId == id {
+			task.ParentId = nil
+		}
+
+		if task.Recurrence != nil && task.Recurrence.NextId != nil && *task.Recurrence.NextId == id {
+			recurrence := *task.Recurrence
+			recurrence.NextId = nil
+			task.Recurrence = &recurrence
+		}
+	}
+
+	r.Task.Range(func(_, value interface{}) bool {
+		if task, ok := value.(*Task); ok {
+			forget(task)
+		}
+
+		return true
+	})
+
+	for _, task := range r.trash {
+		forget(task)
+	}
+
+	return files
+}
@@ -0,0 +1,152 @@
+package repos
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) GetChildren(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to get children")
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		if _, ok := r.ownedTask(owner, id); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to get children")
+		}
+
+		return copyTasks(r.childrenOf(owner, id)), nil
+	}
+}
+
+func (r *repMemory) GetDescendants(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to get descendants")
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		if _, ok := r.ownedTask(owner, id); !ok {
+			return nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to get descendants")
+		}
+
+		var result []*Task
+		queue := []uuid.UUID{id}
+
+		for len(queue) > 0 {
+			children := r.childrenOf(owner, queue[0])
+			queue = queue[1:]
+
+			for _, child := range children {
+				result = append(result, child)
+				queue = append(queue, child.Id)
+			}
+		}
+
+		return copyTasks(result), nil
+	}
+}
+
+// ownedTask - задача владельца
+func (r *repMemory) ownedTask(owner string, id uuid.UUID) (*Task, bool) {
+	value, ok := r.Task.Load(id)
+	if !ok {
+		return nil, false
+	}
+
+	task, ok := value.(*Task)
+	if !ok || task.OwnerId != owner {
+		return nil, false
+	}
+
+	return task, true
+}
+
+// childrenOf - прямые подзадачи в порядке создания; вызывается под mu
+func (r *repMemory) childrenOf(owner string, id uuid.UUID) []*Task {
+	children := make([]*Task, 0, len(r.children[id]))
+
+	// подзадачи из корзины есть в индексе, но не в Task
+	for childId := range r.children[id] {
+		if task, ok := r.ownedTask(owner, childId); ok {
+			children = append(children, task)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].CreatedAt.Before(children[j].CreatedAt)
+	})
+
+	return children
+}
+
+// setParent - перенос задачи id от родителя old к new в индексе подзадач; вызывается под mu
+func (r *repMemory) setParent(id uuid.UUID, old, new *uuid.UUID) {
+	if old != nil {
+		delete(r.children[*old], id)
+		if len(r.children[*old]) == 0 {
+			delete(r.children, *old)
+		}
+	}
+
+	if new != nil {
+		if r.children[*new] == nil {
+			r.children[*new] = make(map[uuid.UUID]struct{})
+		}
+		r.children[*new][id] = struct{}{}
+	}
+}
+
+// wouldCycle - станет ли parent предком самого себя, если id перенести под него
+func (r *repMemory) wouldCycle(owner string, id, parent uuid.UUID) bool {
+	seen := make(map[uuid.UUID]bool)
+
+	for cur := &parent; cur != nil; {
+		if *cur == id {
+			return true
+		}
+
+		if seen[*cur] {
+			return false
+		}
+		seen[*cur] = true
+
+		// предок может быть в корзине: после восстановления цикл всё равно появится
+		task, ok := r.ownedTask(owner, *cur)
+		if !ok {
+			if task, ok = r.trash[*cur]; !ok || task.OwnerId != owner {
+				return false
+			}
+		}
+		cur = task.ParentId
+	}
+
+	return false
+}
+
+func (r *repMemory) hasOpenChildren(owner string, id uuid.UUID) bool {
+	for _, child := range r.childrenOf(owner, id) {
+		if !r.workflow.IsDone(child.Status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyTasks(tasks []*Task) []Task {
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, *t)
+	}
+
+	return result
+}
@@ -0,0 +1,310 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+func (r *repMemory) StartTimer(ctx context.Context, owner string, worklog Worklog) (Worklog, error) {
+	select {
+	case <-ctx.Done():
+		return Worklog{}, errors.Wrap(ctx.Err(), "failed to start timer")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, worklog.TaskId); !ok {
+			return Worklog{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to start timer")
+		}
+
+		if _, ok := r.timers[worklog.User]; ok {
+			return Worklog{}, errors.Wrap(myerr.ErrTimerRunning, "failed to start timer")
+		}
+
+		worklog.Start = time.Now()
+		worklog.End = nil
+		worklog.Duration = 0
+		worklog.CreatedAt = worklog.Start
+
+		stored := worklog
+		r.worklogs[worklog.TaskId] = append(r.worklogs[worklog.TaskId], &stored)
+		r.timers[worklog.User] = &stored
+
+		return worklog, r.appendAudit(owner, AuditWorklogAdd, worklog.TaskId, nil, worklog)
+	}
+}
+
+func (r *repMemory) StopTimer(ctx context.Context, owner string, taskId uuid.UUID, user, note string) (Worklog, error) {
+	select {
+	case <-ctx.Done():
+		return Worklog{}, errors.Wrap(ctx.Err(), "failed to stop timer")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return Worklog{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to stop timer")
+		}
+
+		worklog, ok := r.timers[user]
+		if !ok || worklog.TaskId != taskId {
+			return Worklog{}, errors.Wrap(myerr.ErrNoTimer, "failed to stop timer")
+		}
+
+		before := *worklog
+
+		if note != "" {
+			worklog.Note = note
+		}
+
+		r.stopTimer(worklog, time.Now())
+
+		return *worklog, r.appendAudit(owner, AuditWorklogUpdate, taskId, before, worklog)
+	}
+}
+
+func (r *repMemory) AddWorklog(ctx context.Context, owner string, worklog Worklog) (Worklog, error) {
+	select {
+	case <-ctx.Done():
+		return Worklog{}, errors.Wrap(ctx.Err(), "failed to add worklog")
+	default:
+		if worklog.End == nil || !worklog.End.After(worklog.Start) {
+			return Worklog{}, errors.Wrap(myerr.ErrWorklogRange, "failed to add worklog")
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, worklog.TaskId); !ok {
+			return Worklog{}, errors.Wrap(myerr.ErrTaskNotFound, "failed to add worklog")
+		}
+
+		worklog.Duration = worklog.seconds()
+		worklog.CreatedAt = time.Now()
+
+		stored := worklog
+		r.worklogs[worklog.TaskId] = append(r.worklogs[worklog.TaskId], &stored)
+
+		return worklog, r.appendAudit(owner, AuditWorklogAdd, worklog.TaskId, nil, worklog)
+	}
+}
+
+func (r *repMemory) ListWorklogs(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Worklog, []UserTime, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, errors.Wrap(ctx.Err(), "failed to list worklogs")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.ownedTask(owner, taskId); !ok {
+			return nil, nil, errors.Wrap(myerr.ErrTaskNotFound, "failed to list worklogs")
+		}
+
+		all := make([]Worklog, 0, len(r.worklogs[taskId]))
+		byUser := make(map[string]int64)
+
+		for _, w := range r.worklogs[taskId] {
+			all = append(all, *w)
+			byUser[w.User] += w.Duration
+		}
+
+		sort.Slice(all, func(i, j int) bool {
+			if !all[i].Start.Equal(all[j].Start) {
+				return all[i].Start.Before(all[j].Start)
+			}
+
+			return all[i].Id.String() < all[j].Id.String()
+		})
+
+		totals := make([]UserTime, 0, len(byUser))
+		for user, d := range byUser {
+			totals = append(totals, UserTime{User: user, Duration: d})
+		}
+
+		sort.Slice(totals, func(i, j int) bool {
+			return totals[i].User < totals[j].User
+		})
+
+		start := (page - 1) * limit
+		if page > 1 && start >= len(all) {
+			return []Worklog{}, totals, errors.Wrap(myerr.ErrRange, "failed to list worklogs")
+		}
+
+		end := start + limit
+		if end > len(all) {
+			end = len(all)
+		}
+
+		return all[start:end], totals, nil
+	}
+}
+
+func (r *repMemory) UpdateWorklog(ctx context.Context, owner string, taskId, id uuid.UUID, change WorklogUpdate) (Worklog, error) {
+	select {
+	case <-ctx.Done():
+		return Worklog{}, errors.Wrap(ctx.Err(), "failed to update worklog")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		worklog, err := r.ownedWorklog(owner, taskId, id)
+		if err != nil {
+			return Worklog{}, errors.Wrap(err, "failed to update worklog")
+		}
+
+		updated := *worklog
+		if change.Start != nil {
+			updated.Start = *change.Start
+		}
+
+		if change.End != nil {
+			updated.End = change.End
+		}
+
+		if change.Note != nil {
+			updated.Note = *change.Note
+		}
+
+		if updated.End != nil && !updated.End.After(updated.Start) {
+			return Worklog{}, errors.Wrap(myerr.ErrWorklogRange, "failed to update worklog")
+		}
+
+		before := *worklog
+		running := worklog.End == nil
+		*worklog = updated
+
+		if running && worklog.End != nil {
+			r.stopTimer(worklog, *worklog.End)
+		}
+
+		worklog.Duration = worklog.seconds()
+
+		return *worklog, r.appendAudit(owner, AuditWorklogUpdate, taskId, before, worklog)
+	}
+}
+
+func (r *repMemory) DeleteWorklog(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to delete worklog")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		worklog, err := r.ownedWorklog(owner, taskId, id)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete worklog")
+		}
+
+		if r.timers[worklog.User] == worklog {
+			delete(r.timers, worklog.User)
+		}
+
+		worklogs := r.worklogs[taskId]
+		for i, w := range worklogs {
+			if w.Id == id {
+				r.worklogs[taskId] = append(worklogs[:i:i], worklogs[i+1:]...)
+				break
+			}
+		}
+
+		return r.appendAudit(owner, AuditWorklogDelete, taskId, worklog, nil)
+	}
+}
+
+func (r *repMemory) WorklogReport(ctx context.Context, owner string, from, to time.Time) ([]TaskTime, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to build worklog report")
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		report := make([]TaskTime, 0)
+
+		r.Task.Range(func(key, value interface{}) bool {
+			task, ok := value.(*Task)
+			if !ok || task.OwnerId != owner {
+				return true
+			}
+
+			var total int64
+			for _, w := range r.worklogs[task.Id] {
+				total += w.overlap(from, to)
+			}
+
+			if total > 0 {
+				report = append(report, TaskTime{TaskId: task.Id, Title: task.Title, Duration: total})
+			}
+
+			return true
+		})
+
+		sortReport(report)
+
+		return report, nil
+	}
+}
+
+// sortReport - больше всего времени сверху
+func sortReport(report []TaskTime) {
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Duration != report[j].Duration {
+			return report[i].Duration > report[j].Duration
+		}
+
+		return report[i].TaskId.String() < report[j].TaskId.String()
+	})
+}
+
+// stopTimer - завершение записи запущенного таймера; вызывается под mu
+func (r *repMemory) stopTimer(worklog *Worklog, end time.Time) {
+	// таймер мог быть запущен в будущем после правки начала
+	if !end.After(worklog.Start) {
+		end = worklog.Start
+	}
+
+	worklog.End = &end
+	worklog.Duration = worklog.seconds()
+
+	if r.timers[worklog.User] == worklog {
+		delete(r.timers, worklog.User)
+	}
+}
+
+// stopTaskTimers - остановка таймеров задачи, например при переносе в корзину; вызывается под mu
+func (r *repMemory) stopTaskTimers(taskId uuid.UUID, end time.Time) {
+	for _, w := range r.worklogs[taskId] {
+		if w.End == nil {
+			r.stopTimer(w, end)
+		}
+	}
+}
+
+// ownedWorklog - запись времени по задаче владельца, которую может менять только автор; вызывается под mu
+func (r *repMemory) ownedWorklog(owner string, taskId, id uuid.UUID) (*Worklog, error) {
+	if _, ok := r.ownedTask(owner, taskId); !ok {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	for _, w := range r.worklogs[taskId] {
+		if w.Id != id {
+			continue
+		}
+
+		if w.User != owner {
+			return nil, myerr.ErrWorklogAuthor
+		}
+
+		return w, nil
+	}
+
+	return nil, myerr.ErrWorklogNotFound
+}
@@ -0,0 +1,162 @@
+package repos
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/workflow"
+)
+
+const (
+	alice = "alice"
+	bob   = "bob"
+)
+
+// backends - хранилища для проверки; PostgreSQL - только если заданы DB_* (база с применёнными миграциями)
+func backends(t *testing.T) map[string]Repository {
+	t.Helper()
+
+	var wfCfg config.Workflow
+	if err := envconfig.Process("", &wfCfg); err != nil {
+		t.Fatalf("load workflow config: %v", err)
+	}
+
+	wf, err := workflow.New(wfCfg)
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+
+	result := map[string]Repository{"memory": NewMemory(wf)}
+
+	if os.Getenv("DB_HOST") == "" {
+		return result
+	}
+
+	var pgCfg config.PostgreSQL
+	if err = envconfig.Process("", &pgCfg); err != nil {
+		t.Fatalf("load postgres config: %v", err)
+	}
+
+	pg, err := NewPostgres(context.Background(), pgCfg, wf)
+	if err != nil {
+		t.Fatalf("connect postgres: %v", err)
+	}
+	result["postgres"] = pg
+
+	return result
+}
+
+func TestOwnerIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	for name, rep := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			id := uuid.New()
+			if err := rep.CreateTask(ctx, alice, TaskCreate{Id: id, Title: "alice task"}); err != nil {
+				t.Fatalf("create task: %v", err)
+			}
+
+			tests := []struct {
+				name string
+				call func() error
+			}{
+				{"get", func() error {
+					_, err := rep.GetTask(ctx, bob, id)
+					return err
+				}},
+				{"list", func() error {
+					tasks, err := rep.GetAllTasks(ctx, bob, TaskFilter{Page: 1, Limit: 100})
+					for _, task := range tasks {
+						if task.Id == id {
+							return nil
+						}
+					}
+					return err
+				}},
+				{"children", func() error {
+					_, err := rep.GetChildren(ctx, bob, id)
+					return err
+				}},
+				{"update", func() error {
+					_, err := rep.UpdateTask(ctx, bob, UpdateTask{Title: "taken"}, id)
+					return err
+				}},
+				{"delete", func() error {
+					return rep.DeleteTask(ctx, bob, id)
+				}},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					if err := tt.call(); !errors.Is(err, myerr.ErrTaskNotFound) {
+						t.Fatalf("got %v, want %v", err, myerr.ErrTaskNotFound)
+					}
+				})
+			}
+
+			task, err := rep.GetTask(ctx, alice, id)
+			if err != nil {
+				t.Fatalf("owner lost the task: %v", err)
+			}
+
+			if task.Title != "alice task" || task.DeletedAt != nil {
+				t.Fatalf("task changed by another owner: %+v", task)
+			}
+		})
+	}
+}
+
+func TestCreateTaskDuplicateID(t *testing.T) {
+	ctx := context.Background()
+
+	for name, rep := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			id := uuid.New()
+			if err := rep.CreateTask(ctx, alice, TaskCreate{Id: id, Title: "alice task"}); err != nil {
+				t.Fatalf("create task: %v", err)
+			}
+
+			tests := []struct {
+				name  string
+				owner string
+			}{
+				{"other owner", bob},
+				{"same owner", alice},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					err := rep.CreateTask(ctx, tt.owner, TaskCreate{Id: id, Title: "duplicate"})
+					if !errors.Is(err, myerr.ErrTaskExists) {
+						t.Fatalf("got %v, want %v", err, myerr.ErrTaskExists)
+					}
+				})
+			}
+
+			task, err := rep.GetTask(ctx, alice, id)
+			if err != nil || task.Title != "alice task" {
+				t.Fatalf("task taken over: %+v, %v", task, err)
+			}
+
+			if _, err = rep.GetTask(ctx, bob, id); !errors.Is(err, myerr.ErrTaskNotFound) {
+				t.Fatalf("other owner sees the task: %v", err)
+			}
+
+			// id задачи в корзине тоже занят
+			if err = rep.DeleteTask(ctx, alice, id); err != nil {
+				t.Fatalf("delete task: %v", err)
+			}
+
+			if err = rep.CreateTask(ctx, bob, TaskCreate{Id: id, Title: "duplicate"}); !errors.Is(err, myerr.ErrTaskExists) {
+				t.Fatalf("got %v, want %v", err, myerr.ErrTaskExists)
+			}
+		})
+	}
+}
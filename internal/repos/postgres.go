@@ -3,31 +3,106 @@ package repos
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 
 	"github.com/volkowlad/week4/internal/config"
 	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/workflow"
 )
 
 // SQL-запрос на вставку задачи
 const (
-	insertTaskQuery  = `INSERT INTO tasks (id, title, description) VALUES ($1, $2, $3);`
-	selectTasksQuery = `SELECT id, title, description, status, created_at, updated_at FROM tasks WHERE id = $1;`
-	selectAllTasks   = `SELECT id, title, description, status, created_at, updated_at FROM tasks LIMIT $1 OFFSET $2;`
-	deleteTask       = `DELETE FROM tasks WHERE id = $1;`
+	taskColumns = `id, owner_id, title, description, status, due_at, overdue, parent_id, project_id, created_at, updated_at,
+		deleted_at, completed_at, archived_at, estimate_points, estimate_minutes, recurrence_rule, recurrence_tz, recurrence_start, recurrence_seq, recurrence_next_id,
+		COALESCE((SELECT array_agg(g.name ORDER BY g.name) FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.task_id = tasks.id), '{}') AS tags,
+		COALESCE((SELECT array_agg(ta.assignee ORDER BY ta.assignee) FROM task_assignees ta
+			WHERE ta.task_id = tasks.id), '{}') AS assignees,
+		(SELECT count(*) FILTER (WHERE tc.done) FROM task_checklist tc WHERE tc.task_id = tasks.id) AS checklist_done,
+		(SELECT count(*) FROM task_checklist tc WHERE tc.task_id = tasks.id) AS checklist_total, custom_fields`
+	insertTaskQuery = `INSERT INTO tasks (id, owner_id, title, description, status, due_at, parent_id, project_id,
+		recurrence_rule, recurrence_tz, recurrence_start, recurrence_seq, estimate_points, estimate_minutes, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, COALESCE($15::jsonb, '{}'));`
+	// задачи в корзине (deleted_at IS NOT NULL) видны только через запросы корзины
+	selectTasksQuery = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL;`
+	trashTask        = `UPDATE tasks SET deleted_at = now(), updated_at = now()
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		RETURNING ` + taskColumns + `;`
+	selectTaskStatus = `SELECT status FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL FOR NO KEY UPDATE;`
+	// снимок задачи до изменения, строка заблокирована до конца транзакции
+	lockTaskQuery = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		FOR NO KEY UPDATE;`
+	// $2 - выполненные статусы из workflow
+	clearOverdueTask = `UPDATE tasks SET overdue = false
+		WHERE id = $1 AND overdue AND (due_at IS NULL OR due_at >= now() OR status = ANY($2));`
+	selectOverdueTasks = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE NOT overdue AND due_at < $1 AND status <> ALL($2) AND deleted_at IS NULL
+		FOR NO KEY UPDATE;`
+	flagOverdueTasks = `UPDATE tasks SET overdue = true WHERE id = ANY($1) RETURNING ` + taskColumns + `;`
+
+	uniqueViolation = "23505"
+	tasksPkey       = "tasks_pkey"
 )
 
+func scanTask(row pgx.Row) (Task, error) {
+	var (
+		task  Task
+		rule  *string
+		tz    *string
+		start *time.Time
+		seq   *int
+		next  *uuid.UUID
+		done  int
+		total int
+	)
+
+	err := row.Scan(&task.Id, &task.OwnerId, &task.Title, &task.Description, &task.Status,
+		&task.DueAt, &task.Overdue, &task.ParentId, &task.ProjectId, &task.CreatedAt, &task.UpdatedAt,
+		&task.DeletedAt, &task.CompletedAt, &task.ArchivedAt, &task.Points, &task.Minutes, &rule, &tz, &start, &seq, &next, &task.Tags, &task.Assignees,
+		&done, &total, &task.Fields)
+
+	// CHECK в миграции гарантирует, что при заданном правиле заполнены и остальные поля
+	if err == nil && rule != nil && tz != nil && start != nil && seq != nil {
+		task.Recurrence = &Recurrence{Rule: *rule, TZ: *tz, Start: *start, Seq: *seq, NextId: next}
+	}
+
+	if err == nil && total > 0 {
+		task.Progress = &ChecklistProgress{Done: done, Total: total}
+	}
+
+	return task, err
+}
+
+func collectTasks(rows pgx.Rows) ([]Task, error) {
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
 type repPostgres struct {
-	pool *pgxpool.Pool
+	pool     *pgxpool.Pool
+	workflow *workflow.Workflow
 }
 
 // NewRepository - создание нового экземпляра репозитория с подключением к PostgreSQL
-func NewPostgres(ctx context.Context, cfg config.PostgreSQL) (Repository, error) {
+func NewPostgres(ctx context.Context, cfg config.PostgreSQL, wf *workflow.Workflow) (Repository, error) {
 	// Формируем строку подключения
 	connString := fmt.Sprintf(
 		`user=%s password=%s host=%s port=%d dbname=%s sslmode=%s 
@@ -58,22 +133,75 @@ func NewPostgres(ctx context.Context, cfg config.PostgreSQL) (Repository, error)
 		return nil, errors.Wrap(err, "failed to create PostgreSQL connection pool")
 	}
 
-	return &repPostgres{pool}, nil
+	return &repPostgres{pool: pool, workflow: wf}, nil
 }
 
 // CreateTask - вставка новой задачи в таблицу tasks
-func (r *repPostgres) CreateTask(ctx context.Context, task TaskCreate) error {
-	_, err := r.pool.Exec(ctx, insertTaskQuery, task.Id, task.Title, task.Description)
+func (r *repPostgres) CreateTask(ctx context.Context, owner string, task TaskCreate) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if task.ParentId != nil {
+		if err = lockTaskTree(ctx, tx, owner); err != nil {
+			return err
+		}
+
+		if err = checkParent(ctx, tx, owner, *task.ParentId); err != nil {
+			return err
+		}
+	}
+
+	projectId, err := taskProject(ctx, tx, owner, task.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	defs, err := projectFields(ctx, tx, projectId)
+	if err != nil {
+		return err
+	}
+
+	fields, err := applyFields(ctx, defs, nil, task.Fields, true)
+	if err != nil {
+		return err
+	}
+
+	rule, tz, start, seq := recurrenceArgs(task.Recurrence)
+	_, err = tx.Exec(ctx, insertTaskQuery, task.Id, owner, task.Title, task.Description, r.workflow.Initial(), task.DueAt,
+		task.ParentId, projectId, rule, tz, start, seq, task.Points, task.Minutes, fields)
 	if err != nil {
+		if isConstraintViolation(err, tasksPkey) {
+			return myerr.ErrTaskExists
+		}
+
 		return errors.Wrap(err, "failed to insert task")
 	}
-	return nil
+
+	if err = setTaskTags(ctx, tx, task.Id, task.Tags); err != nil {
+		return err
+	}
+
+	created, err := scanTask(tx.QueryRow(ctx, selectTasksQuery, task.Id, owner))
+	if err != nil {
+		return errors.Wrap(err, "failed to query task")
+	}
+
+	if err = addRevision(ctx, tx, owner, created); err != nil {
+		return err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditCreate, created.Id, nil, created); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to insert task")
 }
 
-func (r *repPostgres) GetTask(ctx context.Context, id uuid.UUID) (Task, error) {
-	var task Task
-	err := r.pool.QueryRow(ctx, selectTasksQuery, id).
-		Scan(&task.Id, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt)
+func (r *repPostgres) GetTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
+	task, err := scanTask(r.pool.QueryRow(ctx, selectTasksQuery, id, owner))
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return task, myerr.ErrTaskNotFound
@@ -85,25 +213,68 @@ func (r *repPostgres) GetTask(ctx context.Context, id uuid.UUID) (Task, error) {
 	return task, nil
 }
 
-func (r *repPostgres) GetAllTasks(ctx context.Context, page, limit int) ([]Task, error) {
-	var tasks []Task
+func (r *repPostgres) GetAllTasks(ctx context.Context, owner string, filter TaskFilter) ([]Task, error) {
+	where := []string{"owner_id = $1", "deleted_at IS NULL", "archived_at IS NULL"}
+	if filter.Archived {
+		where[2] = "archived_at IS NOT NULL"
+	}
+	args := []interface{}{owner}
 
-	offset := (page - 1) * limit
+	if filter.DueBefore != nil {
+		args = append(args, *filter.DueBefore)
+		where = append(where, fmt.Sprintf("due_at < $%d", len(args)))
+	}
 
-	rows, err := r.pool.Query(ctx, selectAllTasks, limit, offset)
-	if err != nil {
-		return tasks, errors.Wrap(err, "failed to query tasks")
+	if filter.DueAfter != nil {
+		args = append(args, *filter.DueAfter)
+		where = append(where, fmt.Sprintf("due_at > $%d", len(args)))
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var task Task
-		if err = rows.Scan(&task.Id, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt); err != nil {
-			return tasks, errors.Wrap(err, "failed to query tasks")
-		}
-		tasks = append(tasks, task)
+	if filter.Overdue {
+		args = append(args, filter.Now, r.workflow.Done())
+		where = append(where, fmt.Sprintf("due_at < $%d AND status <> ALL($%d)", len(args)-1, len(args)))
+	}
+
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		where = append(where, fmt.Sprintf(`EXISTS (SELECT 1 FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.task_id = tasks.id AND g.name = $%d)`, len(args)))
+	}
+
+	if filter.ProjectId != nil {
+		args = append(args, *filter.ProjectId)
+		where = append(where, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+
+	if filter.Assignee != "" {
+		args = append(args, filter.Assignee)
+		where = append(where, fmt.Sprintf(`EXISTS (SELECT 1 FROM task_assignees ta
+			WHERE ta.task_id = tasks.id AND ta.assignee = $%d)`, len(args)))
+	}
+
+	// ключи по порядку, чтобы одинаковые фильтры давали один и тот же запрос
+	keys := make([]string, 0, len(filter.Fields))
+	for key := range filter.Fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		args = append(args, key, filter.Fields[key])
+		where = append(where, fmt.Sprintf("custom_fields ->> $%d::text = $%d", len(args)-1, len(args)))
 	}
-	if err = rows.Err(); err != nil {
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE %s ORDER BY created_at, id LIMIT $%d OFFSET $%d",
+		taskColumns, strings.Join(where, " AND "), len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query tasks")
+	}
+
+	tasks, err := collectTasks(rows)
+	if err != nil {
 		return tasks, errors.Wrap(err, "failed to query tasks")
 	}
 
@@ -114,17 +285,40 @@ func (r *repPostgres) GetAllTasks(ctx context.Context, page, limit int) ([]Task,
 	return tasks, nil
 }
 
-func (r *repPostgres) DeleteTask(ctx context.Context, id uuid.UUID) error {
-	_, err := r.pool.Exec(ctx, deleteTask, id)
+func (r *repPostgres) DeleteTask(ctx context.Context, owner string, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockTask(ctx, tx, owner, id)
+	if err != nil {
+		return err
+	}
+
+	task, err := scanTask(tx.QueryRow(ctx, trashTask, id, owner))
 	if err != nil {
 		return errors.Wrap(err, "failed to delete task")
 	}
 
-	return nil
+	if _, err = tx.Exec(ctx, stopTaskTimers, id); err != nil {
+		return errors.Wrap(err, "failed to stop timers")
+	}
+
+	if err = addRevision(ctx, tx, owner, task); err != nil {
+		return err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditDelete, id, before, nil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete task")
 }
 
-func (r *repPostgres) UpdateTask(ctx context.Context, task UpdateTask, id uuid.UUID) (Task, error) {
-	setValues := make([]string, 0)
+func (r *repPostgres) UpdateTask(ctx context.Context, owner string, task UpdateTask, id uuid.UUID) (Task, error) {
+	setValues := []string{"updated_at=now()"}
 	args := make([]interface{}, 0)
 	argId := 1
 	var newTask Task
@@ -139,38 +333,257 @@ func (r *repPostgres) UpdateTask(ctx context.Context, task UpdateTask, id uuid.U
 		setValues = append(setValues, fmt.Sprintf("description=$%d", argId))
 		args = append(args, task.Description)
 		argId++
+	} else if task.ClearDescription {
+		setValues = append(setValues, "description=''")
 	}
 
-	if task.Status == "in_progress" || task.Status == "done" {
-		setValues = append(setValues, fmt.Sprintf("status=$%d", argId))
-		args = append(args, task.Status)
+	if task.Points != nil {
+		setValues = append(setValues, fmt.Sprintf("estimate_points=$%d", argId))
+		args = append(args, *task.Points)
 		argId++
+	} else if task.ClearPoints {
+		setValues = append(setValues, "estimate_points=NULL")
 	}
 
-	setQuery := strings.Join(setValues, ", ")
-	query := fmt.Sprintf("UPDATE tasks SET %s WHERE id = $%d", setQuery, argId)
-	args = append(args, id)
+	if task.Minutes != nil {
+		setValues = append(setValues, fmt.Sprintf("estimate_minutes=$%d", argId))
+		args = append(args, *task.Minutes)
+		argId++
+	} else if task.ClearMinutes {
+		setValues = append(setValues, "estimate_minutes=NULL")
+	}
+
+	if task.Status != "" {
+		// время выполнения сохраняется при переходе между выполненными статусами,
+		// уход из выполненного статуса возвращает задачу из архива
+		setValues = append(setValues, fmt.Sprintf("status=$%d", argId),
+			fmt.Sprintf("completed_at = CASE WHEN $%d = ANY($%d) THEN COALESCE(completed_at, now()) END", argId, argId+1),
+			fmt.Sprintf("archived_at = CASE WHEN $%d = ANY($%d) THEN archived_at END", argId, argId+1))
+		args = append(args, task.Status, r.workflow.Done())
+		argId += 2
+	}
+
+	if task.DueAt != nil {
+		setValues = append(setValues, fmt.Sprintf("due_at=$%d", argId))
+		args = append(args, task.DueAt)
+		argId++
+	} else if task.ClearDueAt {
+		setValues = append(setValues, "due_at=NULL")
+	}
+
+	if task.ParentId != nil {
+		setValues = append(setValues, fmt.Sprintf("parent_id=$%d", argId))
+		args = append(args, task.ParentId)
+		argId++
+	} else if task.ClearParent {
+		setValues = append(setValues, "parent_id=NULL")
+	}
+
+	if task.ProjectId != nil {
+		setValues = append(setValues, fmt.Sprintf("project_id=$%d", argId))
+		args = append(args, task.ProjectId)
+		argId++
+	}
+
+	if task.Recurrence != nil {
+		rule, tz, start, seq := recurrenceArgs(task.Recurrence)
+		setValues = append(setValues, fmt.Sprintf("recurrence_rule=$%d, recurrence_tz=$%d, recurrence_start=$%d, "+
+			"recurrence_seq=$%d, recurrence_next_id=NULL", argId, argId+1, argId+2, argId+3))
+		args = append(args, rule, tz, start, seq)
+		argId += 4
+	} else if task.ClearRecurrence {
+		setValues = append(setValues, "recurrence_rule=NULL, recurrence_tz=NULL, recurrence_start=NULL, "+
+			"recurrence_seq=NULL, recurrence_next_id=NULL")
+	}
 
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return newTask, errors.Wrap(err, "failed to start transaction")
 	}
+	defer tx.Rollback(ctx)
+
+	if err = r.checkTransition(ctx, tx, owner, id, task.Status); err != nil {
+		return newTask, err
+	}
+
+	if err = r.checkHierarchy(ctx, tx, owner, id, task); err != nil {
+		return newTask, err
+	}
+
+	if err = r.checkBlockers(ctx, tx, owner, id, task); err != nil {
+		return newTask, err
+	}
+
+	// проект задан явно, taskProject только проверяет его
+	if task.ProjectId != nil {
+		if _, err = taskProject(ctx, tx, owner, task.ProjectId); err != nil {
+			return newTask, err
+		}
+	}
+
+	fields, err := taskFields(ctx, tx, owner, id, task)
+	if err != nil {
+		return newTask, err
+	}
+
+	if fields != nil {
+		setValues = append(setValues, fmt.Sprintf("custom_fields=$%d", argId))
+		args = append(args, fields)
+		argId++
+	}
 
-	_, err = tx.Exec(ctx, query, args...)
+	before, err := lockTask(ctx, tx, owner, id)
+	if err != nil {
+		return newTask, err
+	}
+
+	setQuery := strings.Join(setValues, ", ")
+	query := fmt.Sprintf("UPDATE tasks SET %s WHERE id = $%d AND owner_id = $%d AND deleted_at IS NULL", setQuery, argId, argId+1)
+	args = append(args, id, owner)
+
+	tag, err := tx.Exec(ctx, query, args...)
 	if err != nil {
-		tx.Rollback(ctx)
 		if errors.Cause(err) == pgx.ErrNoRows {
 			return newTask, myerr.ErrTaskNotFound
 		}
 		return newTask, errors.Wrap(err, "failed to update task")
 	}
 
-	err = tx.QueryRow(ctx, selectTasksQuery, id).
-		Scan(&newTask.Id, &newTask.Title, &newTask.Description, &newTask.Status, &newTask.CreatedAt, &newTask.UpdatedAt)
+	if tag.RowsAffected() == 0 {
+		return newTask, myerr.ErrTaskNotFound
+	}
+
+	// Пометка о просрочке снимается, если срок перенесли или задача выполнена
+	if _, err = tx.Exec(ctx, clearOverdueTask, id, r.workflow.Done()); err != nil {
+		return newTask, errors.Wrap(err, "failed to update task")
+	}
+
+	if task.Tags != nil {
+		if _, err = tx.Exec(ctx, deleteTaskTags, id); err != nil {
+			return newTask, errors.Wrap(err, "failed to update task tags")
+		}
+
+		if err = setTaskTags(ctx, tx, id, task.Tags); err != nil {
+			return newTask, err
+		}
+	}
+
+	newTask, err = scanTask(tx.QueryRow(ctx, selectTasksQuery, id, owner))
 	if err != nil {
-		tx.Rollback(ctx)
 		return newTask, errors.Wrap(err, "failed to query task")
 	}
 
+	// строка задачи заблокирована UPDATE, поэтому следующее повторение создаётся один раз
+	if r.workflow.IsDone(task.Status) {
+		if newTask, err = spawnNextOccurrence(ctx, tx, newTask, r.workflow.Initial()); err != nil {
+			return newTask, err
+		}
+	}
+
+	if err = addRevision(ctx, tx, owner, newTask); err != nil {
+		return newTask, err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditUpdate, id, before, newTask); err != nil {
+		return newTask, err
+	}
+
 	return newTask, tx.Commit(ctx)
 }
+
+// lockTask - задача владельца под блокировкой строки до конца транзакции, снимок до изменения для журнала аудита
+func lockTask(ctx context.Context, tx pgx.Tx, owner string, id uuid.UUID) (Task, error) {
+	task, err := scanTask(tx.QueryRow(ctx, lockTaskQuery, id, owner))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return task, myerr.ErrTaskNotFound
+		}
+
+		return task, errors.Wrap(err, "failed to lock task")
+	}
+
+	return task, nil
+}
+
+// isConstraintViolation - нарушение уникальности именно ограничения constraint
+func isConstraintViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
+
+// checkTransition - переход статуса по workflow; строка задачи блокируется до конца транзакции.
+// Блокировка дерева берётся раньше строки, в том же порядке, что и в checkHierarchy.
+func (r *repPostgres) checkTransition(ctx context.Context, tx pgx.Tx, owner string, id uuid.UUID, status string) error {
+	if status == "" {
+		return nil
+	}
+
+	if err := lockTaskTree(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	var current string
+	if err := tx.QueryRow(ctx, selectTaskStatus, id, owner).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return myerr.ErrTaskNotFound
+		}
+
+		return errors.Wrap(err, "failed to query task status")
+	}
+
+	return r.workflow.Check(current, status)
+}
+
+func (r *repPostgres) FlagOverdueTasks(ctx context.Context, now time.Time) ([]Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	tasks, err := updateLockedTasks(ctx, tx, flagOverdueTasks, selectOverdueTasks, now, r.workflow.Done())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to flag overdue tasks")
+	}
+
+	return tasks, errors.Wrap(tx.Commit(ctx), "failed to flag overdue tasks")
+}
+
+// updateLockedTasks - изменение задач всех владельцев фоновым обработчиком. selectQuery блокирует строки
+// и даёт снимки до изменения, update применяется к тем же задачам по id ($1); каждая попадает в журнал аудита
+func updateLockedTasks(ctx context.Context, tx pgx.Tx, update, selectQuery string, args ...interface{}) ([]Task, error) {
+	rows, err := tx.Query(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := collectTasks(rows)
+	if err != nil || len(locked) == 0 {
+		return locked, err
+	}
+
+	before := make(map[uuid.UUID]Task, len(locked))
+	ids := make([]uuid.UUID, 0, len(locked))
+	for _, task := range locked {
+		before[task.Id] = task
+		ids = append(ids, task.Id)
+	}
+
+	if rows, err = tx.Query(ctx, update, ids); err != nil {
+		return nil, err
+	}
+
+	tasks, err := collectTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		if err = appendAudit(ctx, tx, SystemActor, AuditUpdate, task.Id, before[task.Id], task); err != nil {
+			return nil, err
+		}
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,98 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	insertAPIKeyQuery = `INSERT INTO api_keys (id, name, subject, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	selectAPIKeys = `SELECT id, name, subject, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys ORDER BY created_at;`
+	selectAPIKeyByHash = `SELECT id, name, subject, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys WHERE key_hash = $1;`
+	revokeAPIKey = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1;`
+	touchAPIKey  = `UPDATE api_keys SET last_used_at = now() WHERE id = $1;`
+)
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var key APIKey
+	err := row.Scan(&key.Id, &key.Name, &key.Subject, &key.Prefix, &key.Hash, &key.Scopes,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+
+	return key, err
+}
+
+func (r *repPostgres) CreateAPIKey(ctx context.Context, key APIKey) error {
+	_, err := r.pool.Exec(ctx, insertAPIKeyQuery,
+		key.Id, key.Name, key.Subject, key.Prefix, key.Hash, key.Scopes, key.ExpiresAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert api key")
+	}
+
+	return nil
+}
+
+func (r *repPostgres) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	keys := make([]APIKey, 0)
+
+	rows, err := r.pool.Query(ctx, selectAPIKeys)
+	if err != nil {
+		return keys, errors.Wrap(err, "failed to query api keys")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return keys, errors.Wrap(err, "failed to query api keys")
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return keys, errors.Wrap(err, "failed to query api keys")
+	}
+
+	return keys, nil
+}
+
+func (r *repPostgres) GetAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, selectAPIKeyByHash, hash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return key, myerr.ErrAPIKeyNotFound
+		}
+
+		return key, errors.Wrap(err, "failed to query api key")
+	}
+
+	return key, nil
+}
+
+func (r *repPostgres) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke api key")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return myerr.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *repPostgres) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, touchAPIKey, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to touch api key")
+	}
+
+	return nil
+}
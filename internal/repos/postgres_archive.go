@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	// $2 - выполненные статусы из workflow
+	selectArchivable = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE completed_at < $1 AND archived_at IS NULL AND deleted_at IS NULL AND status = ANY($2)
+		FOR NO KEY UPDATE OF tasks;`
+	archiveTasks = `UPDATE tasks SET archived_at = now(), updated_at = now() WHERE id = ANY($1) RETURNING ` + taskColumns + `;`
+	// срок до повторного архивирования отсчитывается заново
+	unarchiveTask = `UPDATE tasks SET archived_at = NULL, completed_at = now(), updated_at = now()
+		WHERE id = $1 AND owner_id = $2 AND archived_at IS NOT NULL AND deleted_at IS NULL
+		RETURNING ` + taskColumns + `;`
+)
+
+func (r *repPostgres) ArchiveTasks(ctx context.Context, before time.Time) ([]Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	tasks, err := updateLockedTasks(ctx, tx, archiveTasks, selectArchivable, before, r.workflow.Done())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to archive tasks")
+	}
+
+	for _, task := range tasks {
+		if err = addRevision(ctx, tx, SystemActor, task); err != nil {
+			return nil, err
+		}
+	}
+
+	return tasks, errors.Wrap(tx.Commit(ctx), "failed to archive tasks")
+}
+
+func (r *repPostgres) UnarchiveTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	snapshot, err := lockTask(ctx, tx, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	task, err := scanTask(tx.QueryRow(ctx, unarchiveTask, id, owner))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Task{}, myerr.ErrNotArchived
+	}
+
+	if err != nil {
+		return task, errors.Wrap(err, "failed to unarchive task")
+	}
+
+	if err = addRevision(ctx, tx, owner, task); err != nil {
+		return task, err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditUpdate, id, snapshot, task); err != nil {
+		return task, err
+	}
+
+	return task, errors.Wrap(tx.Commit(ctx), "failed to unarchive task")
+}
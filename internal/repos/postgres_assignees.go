@@ -0,0 +1,101 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	// updated_at меняется только если назначение действительно добавлено
+	insertAssignee = `WITH ins AS (
+			INSERT INTO task_assignees (task_id, assignee)
+			SELECT id, $3::text FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+			RETURNING task_id
+		)
+		UPDATE tasks SET updated_at = now() WHERE id IN (SELECT task_id FROM ins);`
+	deleteAssignee = `DELETE FROM task_assignees ta USING tasks t
+		WHERE ta.task_id = $1 AND ta.assignee = $3 AND t.id = ta.task_id AND t.owner_id = $2 AND t.deleted_at IS NULL;`
+	touchTask = `UPDATE tasks SET updated_at = now() WHERE id = $1;`
+)
+
+func (r *repPostgres) AssignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockTask(ctx, tx, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	tag, err := tx.Exec(ctx, insertAssignee, id, owner, assignee)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to assign task")
+	}
+
+	task, err := scanTask(tx.QueryRow(ctx, selectTasksQuery, id, owner))
+	if err != nil {
+		return task, errors.Wrap(err, "failed to query task")
+	}
+
+	// ревизия и запись журнала только если исполнитель действительно добавлен
+	if tag.RowsAffected() > 0 {
+		if err = addRevision(ctx, tx, owner, task); err != nil {
+			return task, err
+		}
+
+		if err = appendAudit(ctx, tx, owner, AuditUpdate, id, before, task); err != nil {
+			return task, err
+		}
+	}
+
+	return task, errors.Wrap(tx.Commit(ctx), "failed to assign task")
+}
+
+func (r *repPostgres) UnassignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockTask(ctx, tx, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	tag, err := tx.Exec(ctx, deleteAssignee, id, owner, assignee)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to unassign task")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return Task{}, myerr.ErrNotAssigned
+	}
+
+	if _, err = tx.Exec(ctx, touchTask, id); err != nil {
+		return Task{}, errors.Wrap(err, "failed to unassign task")
+	}
+
+	task, err := scanTask(tx.QueryRow(ctx, selectTasksQuery, id, owner))
+	if err != nil {
+		return task, errors.Wrap(err, "failed to query task")
+	}
+
+	if err = addRevision(ctx, tx, owner, task); err != nil {
+		return task, err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditUpdate, id, before, task); err != nil {
+		return task, err
+	}
+
+	return task, errors.Wrap(tx.Commit(ctx), "failed to unassign task")
+}
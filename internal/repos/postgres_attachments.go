@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	attachmentColumns = `a.id, a.task_id, a.name, a.size, a.content_type, a.sha256, a.created_at`
+	insertAttachment  = `INSERT INTO task_attachments AS a (id, task_id, name, size, content_type, sha256)
+		SELECT $1::uuid, id, $4::text, $5::bigint, $6::text, $7::text FROM tasks
+		WHERE id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		RETURNING ` + attachmentColumns + `;`
+	selectAttachments = `SELECT ` + attachmentColumns + ` FROM task_attachments a
+		WHERE a.task_id = $1 ORDER BY a.created_at, a.id;`
+	selectAttachment = `SELECT ` + attachmentColumns + ` FROM task_attachments a JOIN tasks t ON t.id = a.task_id
+		WHERE a.id = $1 AND a.task_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL;`
+	deleteAttachment = `DELETE FROM task_attachments a USING tasks t
+		WHERE a.id = $1 AND a.task_id = $2 AND t.id = a.task_id AND t.owner_id = $3 AND t.deleted_at IS NULL
+		RETURNING ` + attachmentColumns + `;`
+)
+
+func scanAttachment(row pgx.Row) (Attachment, error) {
+	var a Attachment
+	err := row.Scan(&a.Id, &a.TaskId, &a.Name, &a.Size, &a.ContentType, &a.SHA256, &a.CreatedAt)
+
+	return a, err
+}
+
+func (r *repPostgres) AddAttachment(ctx context.Context, owner string, a Attachment) (Attachment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Attachment{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// вставка идёт только если задача принадлежит владельцу
+	stored, err := scanAttachment(tx.QueryRow(ctx, insertAttachment,
+		a.Id, a.TaskId, owner, a.Name, a.Size, a.ContentType, a.SHA256))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return stored, myerr.ErrTaskNotFound
+		}
+
+		return stored, errors.Wrap(err, "failed to add attachment")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditAttachmentAdd, stored.TaskId, nil, stored); err != nil {
+		return stored, err
+	}
+
+	return stored, errors.Wrap(tx.Commit(ctx), "failed to add attachment")
+}
+
+func (r *repPostgres) ListAttachments(ctx context.Context, owner string, taskId uuid.UUID) ([]Attachment, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "failed to list attachments")
+	}
+
+	if !exists {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, selectAttachments, taskId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list attachments")
+	}
+	defer rows.Close()
+
+	files := make([]Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return files, errors.Wrap(err, "failed to list attachments")
+		}
+		files = append(files, a)
+	}
+
+	return files, errors.Wrap(rows.Err(), "failed to list attachments")
+}
+
+func (r *repPostgres) GetAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) (Attachment, error) {
+	a, err := scanAttachment(r.pool.QueryRow(ctx, selectAttachment, id, taskId, owner))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return a, r.missingAttachment(ctx, owner, taskId)
+		}
+
+		return a, errors.Wrap(err, "failed to get attachment")
+	}
+
+	return a, nil
+}
+
+func (r *repPostgres) DeleteAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := scanAttachment(tx.QueryRow(ctx, deleteAttachment, id, taskId, owner))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return r.missingAttachment(ctx, owner, taskId)
+		}
+
+		return errors.Wrap(err, "failed to delete attachment")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditAttachmentDelete, taskId, deleted, nil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete attachment")
+}
+
+// missingAttachment - какой ошибкой ответить, если вложение не нашлось: нет задачи или нет вложения
+func (r *repPostgres) missingAttachment(ctx context.Context, owner string, taskId uuid.UUID) error {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return errors.Wrap(err, "failed to query task")
+	}
+
+	if !exists {
+		return myerr.ErrTaskNotFound
+	}
+
+	return myerr.ErrNoAttachment
+}
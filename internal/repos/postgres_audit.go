@@ -0,0 +1,128 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Блокировка на время транзакции, чтобы записи добавлялись в цепочку строго по одной
+	lockAuditQuery   = `SELECT pg_advisory_xact_lock(hashtext('audit_log'));`
+	lastAuditHash    = `SELECT hash FROM audit_log ORDER BY seq DESC LIMIT 1;`
+	insertAuditQuery = `INSERT INTO audit_log (task_id, actor, action, before, after, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+	selectAuditColumns = `SELECT seq, task_id, actor, action, before, after, created_at, prev_hash, hash FROM audit_log`
+)
+
+func scanAudit(row pgx.Row) (AuditEntry, error) {
+	var e AuditEntry
+	err := row.Scan(&e.Seq, &e.TaskId, &e.Actor, &e.Action, &e.Before, &e.After, &e.CreatedAt, &e.PrevHash, &e.Hash)
+
+	return e, err
+}
+
+// appendAudit - запись журнала в транзакции изменения: без изменения записи нет, и наоборот.
+// Блокировка цепочки держится до конца транзакции, поэтому запись журнала - последний шаг изменения
+func appendAudit(ctx context.Context, tx pgx.Tx, actor, action string, taskId uuid.UUID, before, after any) error {
+	entry, err := newAuditEntry(actor, action, taskId, before, after)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, lockAuditQuery); err != nil {
+		return errors.Wrap(err, "failed to lock audit log")
+	}
+
+	err = tx.QueryRow(ctx, lastAuditHash).Scan(&entry.PrevHash)
+	if err != nil && err != pgx.ErrNoRows {
+		return errors.Wrap(err, "failed to query last audit entry")
+	}
+
+	entry.CreatedAt = stampAudit()
+	entry.Hash = AuditHash(entry.PrevHash, entry)
+
+	_, err = tx.Exec(ctx, insertAuditQuery, entry.TaskId, entry.Actor, entry.Action,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt, entry.PrevHash, entry.Hash)
+
+	return errors.Wrap(err, "failed to append audit entry")
+}
+
+func (r *repPostgres) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	where := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.TaskId != nil {
+		where = append(where, fmt.Sprintf("task_id = $%d", argId))
+		args = append(args, *filter.TaskId)
+		argId++
+	}
+
+	if filter.Actor != "" {
+		where = append(where, fmt.Sprintf("actor = $%d", argId))
+		args = append(args, filter.Actor)
+		argId++
+	}
+
+	if filter.From != nil {
+		where = append(where, fmt.Sprintf("created_at >= $%d", argId))
+		args = append(args, *filter.From)
+		argId++
+	}
+
+	if filter.To != nil {
+		where = append(where, fmt.Sprintf("created_at <= $%d", argId))
+		args = append(args, *filter.To)
+		argId++
+	}
+
+	query := selectAuditColumns
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY seq LIMIT $%d OFFSET $%d", argId, argId+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	return r.queryAudit(ctx, query, args...)
+}
+
+func (r *repPostgres) AuditChain(ctx context.Context) ([]AuditEntry, error) {
+	return r.queryAudit(ctx, selectAuditColumns+" ORDER BY seq")
+}
+
+func (r *repPostgres) queryAudit(ctx context.Context, query string, args ...interface{}) ([]AuditEntry, error) {
+	entries := make([]AuditEntry, 0)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return entries, errors.Wrap(err, "failed to query audit log")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return entries, errors.Wrap(err, "failed to query audit log")
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return entries, errors.Wrap(err, "failed to query audit log")
+	}
+
+	return entries, nil
+}
+
+// nullJSON - пустой снимок пишется как NULL, а не как пустая строка
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+
+	return string(b)
+}
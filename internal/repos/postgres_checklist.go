@@ -0,0 +1,173 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	checklistColumns = `id, text, done, position`
+	// изменение чек-листа обновляет задачу и держит блокировку её строки до конца транзакции,
+	// поэтому изменения одного чек-листа идут по очереди
+	touchChecklistTask = `UPDATE tasks SET updated_at = now()
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL RETURNING id;`
+	selectChecklist     = `SELECT ` + checklistColumns + ` FROM task_checklist WHERE task_id = $1 ORDER BY position;`
+	insertChecklistItem = `INSERT INTO task_checklist (id, task_id, text, done, position)
+		SELECT $2, $1, $3, $4, COALESCE(MAX(position), 0) + 1 FROM task_checklist WHERE task_id = $1;`
+	selectItemPosition = `SELECT position, (SELECT count(*) FROM task_checklist WHERE task_id = $1)
+		FROM task_checklist WHERE id = $2 AND task_id = $1;`
+	// $3 - прежняя позиция пункта, $4 - новая; пункты между ними сдвигаются на одну
+	moveChecklistItem = `UPDATE task_checklist
+		SET position = CASE WHEN id = $2 THEN $4 WHEN $4 < $3 THEN position + 1 ELSE position - 1 END
+		WHERE task_id = $1 AND position BETWEEN LEAST($3, $4) AND GREATEST($3, $4);`
+	// nil переключает пункт в одном UPDATE, без чтения прежнего значения
+	toggleChecklistItem = `UPDATE task_checklist SET done = COALESCE($3, NOT done) WHERE id = $2 AND task_id = $1;`
+	deleteChecklistItem = `DELETE FROM task_checklist WHERE id = $2 AND task_id = $1 RETURNING position;`
+	closeChecklistGap   = `UPDATE task_checklist SET position = position - 1 WHERE task_id = $1 AND position > $2;`
+	// следующее повторение получает те же пункты, но неотмеченными
+	copyTaskChecklist = `INSERT INTO task_checklist (id, task_id, text, position)
+		SELECT gen_random_uuid(), $2, text, position FROM task_checklist WHERE task_id = $1;`
+)
+
+func (r *repPostgres) ListChecklist(ctx context.Context, owner string, taskId uuid.UUID) ([]ChecklistItem, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "failed to list checklist")
+	}
+
+	if !exists {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	items, err := queryChecklist(ctx, r.pool, taskId)
+
+	return items, errors.Wrap(err, "failed to list checklist")
+}
+
+func (r *repPostgres) AddChecklistItem(ctx context.Context, owner string, taskId uuid.UUID, item ChecklistItem) ([]ChecklistItem, error) {
+	return r.changeChecklist(ctx, owner, taskId, "failed to add checklist item", func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, insertChecklistItem, taskId, item.Id, item.Text, item.Done)
+		return err
+	})
+}
+
+func (r *repPostgres) MoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, position int) ([]ChecklistItem, error) {
+	return r.changeChecklist(ctx, owner, taskId, "failed to move checklist item", func(tx pgx.Tx) error {
+		var from, total int
+		if err := tx.QueryRow(ctx, selectItemPosition, taskId, id).Scan(&from, &total); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myerr.ErrNoChecklistItem
+			}
+
+			return err
+		}
+
+		to := min(max(position, 1), total)
+		if to == from {
+			return nil
+		}
+
+		_, err := tx.Exec(ctx, moveChecklistItem, taskId, id, from, to)
+		return err
+	})
+}
+
+func (r *repPostgres) ToggleChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, done *bool) ([]ChecklistItem, error) {
+	return r.changeChecklist(ctx, owner, taskId, "failed to toggle checklist item", func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, toggleChecklistItem, taskId, id, done)
+		if err == nil && tag.RowsAffected() == 0 {
+			return myerr.ErrNoChecklistItem
+		}
+
+		return err
+	})
+}
+
+func (r *repPostgres) RemoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID) ([]ChecklistItem, error) {
+	return r.changeChecklist(ctx, owner, taskId, "failed to remove checklist item", func(tx pgx.Tx) error {
+		var position int
+		if err := tx.QueryRow(ctx, deleteChecklistItem, taskId, id).Scan(&position); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myerr.ErrNoChecklistItem
+			}
+
+			return err
+		}
+
+		_, err := tx.Exec(ctx, closeChecklistGap, taskId, position)
+		return err
+	})
+}
+
+// changeChecklist - изменение чек-листа в транзакции под блокировкой задачи, возвращает чек-лист после изменения.
+// В журнал аудита пишется чек-лист целиком до и после изменения
+func (r *repPostgres) changeChecklist(ctx context.Context, owner string, taskId uuid.UUID, msg string, change func(tx pgx.Tx) error) ([]ChecklistItem, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	if err = tx.QueryRow(ctx, touchChecklistTask, taskId, owner).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myerr.ErrTaskNotFound
+		}
+
+		return nil, errors.Wrap(err, msg)
+	}
+
+	before, err := queryChecklist(ctx, tx, taskId)
+	if err != nil {
+		return nil, errors.Wrap(err, msg)
+	}
+
+	if err = change(tx); err != nil {
+		if errors.Is(err, myerr.ErrNoChecklistItem) {
+			return nil, err
+		}
+
+		return nil, errors.Wrap(err, msg)
+	}
+
+	items, err := queryChecklist(ctx, tx, taskId)
+	if err != nil {
+		return nil, errors.Wrap(err, msg)
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditChecklist, taskId, before, items); err != nil {
+		return nil, err
+	}
+
+	return items, errors.Wrap(tx.Commit(ctx), msg)
+}
+
+// querier - запросы, общие для пула и транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// queryChecklist - пункты чек-листа по порядку
+func queryChecklist(ctx context.Context, q querier, taskId uuid.UUID) ([]ChecklistItem, error) {
+	rows, err := q.Query(ctx, selectChecklist, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]ChecklistItem, 0)
+	for rows.Next() {
+		var item ChecklistItem
+		if err = rows.Scan(&item.Id, &item.Text, &item.Done, &item.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	commentColumns = `id, task_id, author, body, created_at, edited_at`
+	insertComment  = `INSERT INTO task_comments (id, task_id, author, body) SELECT $1::uuid, id, $3::text, $4::text FROM tasks
+		WHERE id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		RETURNING ` + commentColumns + `;`
+	selectComments = `SELECT ` + commentColumns + ` FROM task_comments WHERE task_id = $1
+		ORDER BY created_at, id LIMIT $2 OFFSET $3;`
+	selectOwnedComment = `SELECT c.id, c.task_id, c.author, c.body, c.created_at, c.edited_at
+		FROM task_comments c JOIN tasks t ON t.id = c.task_id
+		WHERE c.id = $1 AND c.task_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL FOR UPDATE OF c;`
+	updateComment = `UPDATE task_comments SET body = $2, edited_at = now() WHERE id = $1
+		RETURNING ` + commentColumns + `;`
+	deleteComment = `DELETE FROM task_comments WHERE id = $1;`
+)
+
+func scanComment(row pgx.Row) (Comment, error) {
+	var c Comment
+	err := row.Scan(&c.Id, &c.TaskId, &c.Author, &c.Body, &c.CreatedAt, &c.EditedAt)
+
+	return c, err
+}
+
+func (r *repPostgres) AddComment(ctx context.Context, owner string, comment Comment) (Comment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Comment{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// вставка идёт только если задача принадлежит владельцу, автор - он же
+	c, err := scanComment(tx.QueryRow(ctx, insertComment, comment.Id, comment.TaskId, owner, comment.Body))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c, myerr.ErrTaskNotFound
+		}
+
+		return c, errors.Wrap(err, "failed to add comment")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditCommentAdd, c.TaskId, nil, c); err != nil {
+		return c, err
+	}
+
+	return c, errors.Wrap(tx.Commit(ctx), "failed to add comment")
+}
+
+func (r *repPostgres) ListComments(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Comment, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "failed to list comments")
+	}
+
+	if !exists {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, selectComments, taskId, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list comments")
+	}
+	defer rows.Close()
+
+	comments := make([]Comment, 0)
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return comments, errors.Wrap(err, "failed to list comments")
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return comments, errors.Wrap(err, "failed to list comments")
+	}
+
+	if len(comments) == 0 && page > 1 {
+		return comments, myerr.ErrRange
+	}
+
+	return comments, nil
+}
+
+func (r *repPostgres) UpdateComment(ctx context.Context, owner string, taskId, id uuid.UUID, body string) (Comment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Comment{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockComment(ctx, tx, owner, taskId, id)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	c, err := scanComment(tx.QueryRow(ctx, updateComment, id, body))
+	if err != nil {
+		return c, errors.Wrap(err, "failed to update comment")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditCommentUpdate, taskId, before, c); err != nil {
+		return c, err
+	}
+
+	return c, errors.Wrap(tx.Commit(ctx), "failed to update comment")
+}
+
+func (r *repPostgres) DeleteComment(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockComment(ctx, tx, owner, taskId, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, deleteComment, id); err != nil {
+		return errors.Wrap(err, "failed to delete comment")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditCommentDelete, taskId, before, nil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete comment")
+}
+
+// lockComment - комментарий задачи владельца, который может менять только автор;
+// строка блокируется до конца транзакции, комментарий - снимок до изменения для журнала аудита
+func lockComment(ctx context.Context, tx pgx.Tx, owner string, taskId, id uuid.UUID) (Comment, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return Comment{}, errors.Wrap(err, "failed to query task")
+	}
+
+	if !exists {
+		return Comment{}, myerr.ErrTaskNotFound
+	}
+
+	c, err := scanComment(tx.QueryRow(ctx, selectOwnedComment, id, taskId, owner))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c, myerr.ErrCommentNotFound
+		}
+
+		return c, errors.Wrap(err, "failed to query comment")
+	}
+
+	if c.Author != owner {
+		return c, myerr.ErrCommentAuthor
+	}
+
+	return c, nil
+}
@@ -0,0 +1,178 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	insertDependency = `INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+	deleteDependency = `DELETE FROM task_dependencies WHERE task_id = $1 AND blocker_id = $2;`
+	// Зависит ли $1 от $2 (напрямую или через другие задачи)
+	dependencyCycleQuery = `WITH RECURSIVE up AS (
+			SELECT blocker_id FROM task_dependencies WHERE task_id = $1
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d JOIN up ON d.task_id = up.blocker_id
+		)
+		SELECT EXISTS (SELECT 1 FROM up WHERE blocker_id = $2);`
+	selectBlockers = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE id IN (SELECT blocker_id FROM task_dependencies WHERE task_id = $1) AND owner_id = $2 AND deleted_at IS NULL
+		ORDER BY created_at, id;`
+	openBlockersQuery = `SELECT EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.task_id = $1 AND t.owner_id = $2 AND t.status <> ALL($3) AND t.deleted_at IS NULL);`
+	selectOwnerTasks = `SELECT ` + taskColumns + ` FROM tasks WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at, id;`
+	selectOwnerDeps  = `SELECT d.task_id, d.blocker_id FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id JOIN tasks b ON b.id = d.blocker_id
+		WHERE t.owner_id = $1 AND t.deleted_at IS NULL AND b.deleted_at IS NULL;`
+)
+
+func (r *repPostgres) AddDependency(ctx context.Context, owner string, dep Dependency) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// граф зависимостей меняется под той же блокировкой, что и иерархия
+	if err = lockTaskTree(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	for _, id := range []uuid.UUID{dep.TaskId, dep.BlockerId} {
+		var exists bool
+		if err = tx.QueryRow(ctx, taskExistsQuery, id, owner).Scan(&exists); err != nil {
+			return errors.Wrap(err, "failed to query task")
+		}
+
+		if !exists {
+			return myerr.ErrTaskNotFound
+		}
+	}
+
+	if dep.TaskId == dep.BlockerId {
+		return myerr.ErrDependencyCycle
+	}
+
+	var cycle bool
+	if err = tx.QueryRow(ctx, dependencyCycleQuery, dep.BlockerId, dep.TaskId).Scan(&cycle); err != nil {
+		return errors.Wrap(err, "failed to check dependencies")
+	}
+
+	if cycle {
+		return myerr.ErrDependencyCycle
+	}
+
+	tag, err := tx.Exec(ctx, insertDependency, dep.TaskId, dep.BlockerId)
+	if err != nil {
+		return errors.Wrap(err, "failed to add dependency")
+	}
+
+	// повторное добавление ничего не меняет и в журнал не попадает
+	if tag.RowsAffected() > 0 {
+		if err = appendAudit(ctx, tx, owner, AuditDependencyAdd, dep.TaskId, nil, dep); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to add dependency")
+}
+
+func (r *repPostgres) RemoveDependency(ctx context.Context, owner string, dep Dependency) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err = tx.QueryRow(ctx, taskExistsQuery, dep.TaskId, owner).Scan(&exists); err != nil {
+		return errors.Wrap(err, "failed to query task")
+	}
+
+	if !exists {
+		return myerr.ErrTaskNotFound
+	}
+
+	tag, err := tx.Exec(ctx, deleteDependency, dep.TaskId, dep.BlockerId)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove dependency")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return myerr.ErrNoDependency
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditDependencyRemove, dep.TaskId, dep, nil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to remove dependency")
+}
+
+func (r *repPostgres) GetBlockers(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	return r.queryTree(ctx, selectBlockers, owner, id, "failed to get blockers")
+}
+
+func (r *repPostgres) DependencyGraph(ctx context.Context, owner string) ([]Task, []Dependency, error) {
+	rows, err := r.pool.Query(ctx, selectOwnerTasks, owner)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get dependency graph")
+	}
+
+	tasks, err := collectTasks(rows)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get dependency graph")
+	}
+
+	rows, err = r.pool.Query(ctx, selectOwnerDeps, owner)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get dependency graph")
+	}
+	defer rows.Close()
+
+	deps := make([]Dependency, 0)
+	for rows.Next() {
+		var dep Dependency
+		if err = rows.Scan(&dep.TaskId, &dep.BlockerId); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get dependency graph")
+		}
+		deps = append(deps, dep)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get dependency graph")
+	}
+
+	if tasks == nil {
+		tasks = []Task{}
+	}
+
+	return tasks, deps, nil
+}
+
+// checkBlockers - задачу нельзя перевести из начального статуса, пока не выполнены блокирующие её задачи
+func (r *repPostgres) checkBlockers(ctx context.Context, tx pgx.Tx, owner string, id uuid.UUID, task UpdateTask) error {
+	if task.Status == "" || task.Status == r.workflow.Initial() {
+		return nil
+	}
+
+	if err := lockTaskTree(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	var blocked bool
+	if err := tx.QueryRow(ctx, openBlockersQuery, id, owner, r.workflow.Done()).Scan(&blocked); err != nil {
+		return errors.Wrap(err, "failed to check blockers")
+	}
+
+	if blocked {
+		return myerr.ErrTaskBlocked
+	}
+
+	return nil
+}
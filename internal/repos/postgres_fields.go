@@ -0,0 +1,236 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	fieldColumns = `id, project_id, key, name, type, required, min_value, max_value, min_date, max_date, options,
+		created_at, updated_at`
+	// изменение схемы ждёт задачи, которые проверяют значения под FOR SHARE проекта (taskProject, lockFieldsProject)
+	lockProjectForFields = `SELECT id FROM projects WHERE id = $1 AND owner_id = $2 FOR NO KEY UPDATE;`
+	lockFieldsProject    = `SELECT id FROM projects WHERE id = $1 FOR SHARE;`
+	insertField          = `INSERT INTO project_fields (id, project_id, key, name, type, required,
+			min_value, max_value, min_date, max_date, options)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::text[], '{}'))
+		RETURNING ` + fieldColumns + `;`
+	selectFields = `SELECT ` + fieldColumns + ` FROM project_fields WHERE project_id = $1 ORDER BY created_at, id;`
+	updateField  = `UPDATE project_fields SET name = $3, required = $4, min_value = $5, max_value = $6,
+			min_date = $7, max_date = $8, options = COALESCE($9::text[], '{}'), updated_at = now()
+		WHERE project_id = $1 AND key = $2
+		RETURNING ` + fieldColumns + `;`
+	deleteField      = `DELETE FROM project_fields WHERE project_id = $1 AND key = $2;`
+	selectFieldTasks = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE project_id = $1 AND custom_fields ? $2::text
+		FOR NO KEY UPDATE OF tasks;`
+	unsetTaskFields = `UPDATE tasks SET custom_fields = custom_fields - $2::text
+		WHERE id = ANY($1) RETURNING ` + taskColumns + `;`
+	selectTaskFields = `SELECT project_id, custom_fields FROM tasks
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL FOR NO KEY UPDATE;`
+)
+
+func scanField(row pgx.Row) (CustomField, error) {
+	var f CustomField
+	err := row.Scan(&f.Id, &f.ProjectId, &f.Key, &f.Name, &f.Type, &f.Required, &f.Min, &f.Max,
+		&f.MinDate, &f.MaxDate, &f.Options, &f.CreatedAt, &f.UpdatedAt)
+
+	return f, err
+}
+
+func (r *repPostgres) CreateField(ctx context.Context, owner string, field CustomField) (CustomField, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return CustomField{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockProjectFields(ctx, tx, owner, field.ProjectId); err != nil {
+		return CustomField{}, err
+	}
+
+	f, err := scanField(tx.QueryRow(ctx, insertField, field.Id, field.ProjectId, field.Key, field.Name, field.Type,
+		field.Required, field.Min, field.Max, field.MinDate, field.MaxDate, field.Options))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return f, myerr.ErrFieldKeyTaken
+		}
+
+		return f, errors.Wrap(err, "failed to create field")
+	}
+
+	return f, errors.Wrap(tx.Commit(ctx), "failed to create field")
+}
+
+func (r *repPostgres) ListFields(ctx context.Context, owner string, projectId uuid.UUID) ([]CustomField, error) {
+	if _, err := r.GetProject(ctx, owner, projectId); err != nil {
+		return nil, err
+	}
+
+	fields, err := queryFields(ctx, r.pool, projectId)
+
+	return fields, errors.Wrap(err, "failed to list fields")
+}
+
+func (r *repPostgres) UpdateField(ctx context.Context, owner string, projectId uuid.UUID, key string, field CustomField) (CustomField, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return CustomField{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockProjectFields(ctx, tx, owner, projectId); err != nil {
+		return CustomField{}, err
+	}
+
+	f, err := scanField(tx.QueryRow(ctx, updateField, projectId, key, field.Name, field.Required,
+		field.Min, field.Max, field.MinDate, field.MaxDate, field.Options))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return f, myerr.ErrFieldNotFound
+		}
+
+		return f, errors.Wrap(err, "failed to update field")
+	}
+
+	return f, errors.Wrap(tx.Commit(ctx), "failed to update field")
+}
+
+func (r *repPostgres) DeleteField(ctx context.Context, owner string, projectId uuid.UUID, key string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockProjectFields(ctx, tx, owner, projectId); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, deleteField, projectId, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete field")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return myerr.ErrFieldNotFound
+	}
+
+	// значения снимаются и с задач в корзине, чтобы после восстановления не появилось поле без схемы
+	rows, err := tx.Query(ctx, selectFieldTasks, projectId, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock tasks")
+	}
+
+	locked, err := collectTasks(rows)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock tasks")
+	}
+
+	if len(locked) > 0 {
+		before := make(map[uuid.UUID]Task, len(locked))
+		ids := make([]uuid.UUID, 0, len(locked))
+		for _, task := range locked {
+			before[task.Id] = task
+			ids = append(ids, task.Id)
+		}
+
+		if rows, err = tx.Query(ctx, unsetTaskFields, ids, key); err != nil {
+			return errors.Wrap(err, "failed to unset field values")
+		}
+
+		tasks, err := collectTasks(rows)
+		if err != nil {
+			return errors.Wrap(err, "failed to unset field values")
+		}
+
+		for _, task := range tasks {
+			if err = appendAudit(ctx, tx, owner, AuditUpdate, task.Id, before[task.Id], task); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete field")
+}
+
+// lockProjectFields - блокировка проекта владельца на время изменения схемы его полей
+func lockProjectFields(ctx context.Context, tx pgx.Tx, owner string, projectId uuid.UUID) error {
+	if err := tx.QueryRow(ctx, lockProjectForFields, projectId, owner).Scan(&projectId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return myerr.ErrProjectNotFound
+		}
+
+		return errors.Wrap(err, "failed to lock project")
+	}
+
+	return nil
+}
+
+// projectFields - схема полей проекта; строка проекта уже заблокирована taskProject или блокируется здесь
+func projectFields(ctx context.Context, tx pgx.Tx, projectId uuid.UUID) ([]CustomField, error) {
+	if _, err := tx.Exec(ctx, lockFieldsProject, projectId); err != nil {
+		return nil, errors.Wrap(err, "failed to lock project")
+	}
+
+	fields, err := queryFields(ctx, tx, projectId)
+
+	return fields, errors.Wrap(err, "failed to query fields")
+}
+
+// taskFields - значения полей задачи после изменения, nil - значения не меняются
+func taskFields(ctx context.Context, tx pgx.Tx, owner string, id uuid.UUID, task UpdateTask) (map[string]any, error) {
+	if task.Fields == nil && task.ProjectId == nil {
+		return nil, nil
+	}
+
+	var (
+		projectId uuid.UUID
+		stored    map[string]any
+	)
+
+	if err := tx.QueryRow(ctx, selectTaskFields, id, owner).Scan(&projectId, &stored); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myerr.ErrTaskNotFound
+		}
+
+		return nil, errors.Wrap(err, "failed to query task fields")
+	}
+
+	if task.ProjectId != nil && *task.ProjectId != projectId {
+		projectId = *task.ProjectId
+	} else if task.Fields == nil {
+		return nil, nil
+	}
+
+	defs, err := projectFields(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyFields(ctx, defs, stored, task.Fields, true)
+}
+
+func queryFields(ctx context.Context, q querier, projectId uuid.UUID) ([]CustomField, error) {
+	rows, err := q.Query(ctx, selectFields, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := make([]CustomField, 0)
+	for rows.Next() {
+		f, err := scanField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	// номер ревизии по порядку; изменения одной задачи идут под блокировкой её строки
+	insertRevision = `INSERT INTO task_revisions (task_id, rev, actor, snapshot)
+		SELECT $1, COALESCE(MAX(rev), 0) + 1, $2, $3 FROM task_revisions WHERE task_id = $1;`
+	revisionColumns = `task_id, rev, actor, snapshot, created_at`
+	selectRevisions = `SELECT ` + revisionColumns + ` FROM task_revisions WHERE task_id = $1
+		ORDER BY rev LIMIT $2 OFFSET $3;`
+	selectRevision = `SELECT ` + revisionColumns + ` FROM task_revisions WHERE task_id = $1 AND rev = $2;`
+)
+
+// addRevision - снимок задачи в той же транзакции, что и изменение
+func addRevision(ctx context.Context, tx pgx.Tx, owner string, task Task) error {
+	snapshot, err := json.Marshal(task)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal revision")
+	}
+
+	if _, err = tx.Exec(ctx, insertRevision, task.Id, owner, snapshot); err != nil {
+		return errors.Wrap(err, "failed to insert revision")
+	}
+
+	return nil
+}
+
+func scanRevision(row pgx.Row) (Revision, error) {
+	var (
+		rev      Revision
+		snapshot []byte
+	)
+
+	if err := row.Scan(&rev.TaskId, &rev.Rev, &rev.Actor, &snapshot, &rev.CreatedAt); err != nil {
+		return rev, err
+	}
+
+	return rev, json.Unmarshal(snapshot, &rev.Task)
+}
+
+func (r *repPostgres) ListRevisions(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Revision, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "failed to list revisions")
+	}
+
+	if !exists {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, selectRevisions, taskId, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list revisions")
+	}
+	defer rows.Close()
+
+	revisions := make([]Revision, 0)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return revisions, errors.Wrap(err, "failed to list revisions")
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return revisions, errors.Wrap(err, "failed to list revisions")
+	}
+
+	if len(revisions) == 0 && page > 1 {
+		return revisions, myerr.ErrRange
+	}
+
+	return revisions, nil
+}
+
+func (r *repPostgres) GetRevision(ctx context.Context, owner string, taskId uuid.UUID, rev int) (Revision, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return Revision{}, errors.Wrap(err, "failed to get revision")
+	}
+
+	if !exists {
+		return Revision{}, myerr.ErrTaskNotFound
+	}
+
+	revision, err := scanRevision(r.pool.QueryRow(ctx, selectRevision, taskId, rev))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return revision, myerr.ErrNoRevision
+		}
+
+		return revision, errors.Wrap(err, "failed to get revision")
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,219 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	projectColumns = `id, owner_id, key, name, description, archived, created_at, updated_at`
+	insertProject  = `INSERT INTO projects (id, owner_id, key, name, description, archived)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + projectColumns + `;`
+	selectProject        = `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND owner_id = $2;`
+	lockProject          = `SELECT archived FROM projects WHERE id = $1 AND owner_id = $2 FOR SHARE;`
+	lockProjectByKey     = `SELECT id, archived FROM projects WHERE owner_id = $1 AND key = $2 FOR SHARE;`
+	insertDefaultProject = `INSERT INTO projects (id, owner_id, key, name) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner_id, key) DO NOTHING;`
+	lockProjectForDelete = `SELECT id FROM projects WHERE id = $1 AND owner_id = $2 FOR UPDATE;`
+	projectHasTasks      = `SELECT EXISTS (SELECT 1 FROM tasks WHERE project_id = $1);`
+	deleteProject        = `DELETE FROM projects WHERE id = $1 AND owner_id = $2;`
+)
+
+func scanProject(row pgx.Row) (Project, error) {
+	var p Project
+	err := row.Scan(&p.Id, &p.OwnerId, &p.Key, &p.Name, &p.Description, &p.Archived, &p.CreatedAt, &p.UpdatedAt)
+
+	return p, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+func (r *repPostgres) CreateProject(ctx context.Context, owner string, project Project) (Project, error) {
+	p, err := scanProject(r.pool.QueryRow(ctx, insertProject,
+		project.Id, owner, project.Key, project.Name, project.Description, project.Archived))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return p, myerr.ErrProjectKeyTaken
+		}
+
+		return p, errors.Wrap(err, "failed to create project")
+	}
+
+	return p, nil
+}
+
+func (r *repPostgres) GetProject(ctx context.Context, owner string, id uuid.UUID) (Project, error) {
+	p, err := scanProject(r.pool.QueryRow(ctx, selectProject, id, owner))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return p, myerr.ErrProjectNotFound
+		}
+
+		return p, errors.Wrap(err, "failed to get project")
+	}
+
+	return p, nil
+}
+
+func (r *repPostgres) ListProjects(ctx context.Context, owner string, filter ProjectFilter) ([]Project, error) {
+	where := []string{"owner_id = $1"}
+	args := []interface{}{owner}
+
+	if filter.Archived != nil {
+		args = append(args, *filter.Archived)
+		where = append(where, fmt.Sprintf("archived = $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf("SELECT %s FROM projects WHERE %s ORDER BY created_at, id LIMIT $%d OFFSET $%d",
+		projectColumns, strings.Join(where, " AND "), len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list projects")
+	}
+	defer rows.Close()
+
+	projects := make([]Project, 0)
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return projects, errors.Wrap(err, "failed to list projects")
+		}
+		projects = append(projects, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return projects, errors.Wrap(err, "failed to list projects")
+	}
+
+	if len(projects) == 0 && filter.Page > 1 {
+		return projects, myerr.ErrRange
+	}
+
+	return projects, nil
+}
+
+func (r *repPostgres) UpdateProject(ctx context.Context, owner string, id uuid.UUID, project UpdateProject) (Project, error) {
+	setValues := []string{"updated_at=now()"}
+	args := make([]interface{}, 0)
+
+	if project.Key != "" {
+		args = append(args, project.Key)
+		setValues = append(setValues, fmt.Sprintf("key=$%d", len(args)))
+	}
+
+	if project.Name != "" {
+		args = append(args, project.Name)
+		setValues = append(setValues, fmt.Sprintf("name=$%d", len(args)))
+	}
+
+	if project.Description != "" {
+		args = append(args, project.Description)
+		setValues = append(setValues, fmt.Sprintf("description=$%d", len(args)))
+	}
+
+	if project.Archived != nil {
+		args = append(args, *project.Archived)
+		setValues = append(setValues, fmt.Sprintf("archived=$%d", len(args)))
+	}
+
+	args = append(args, id, owner)
+	query := fmt.Sprintf("UPDATE projects SET %s WHERE id = $%d AND owner_id = $%d RETURNING %s",
+		strings.Join(setValues, ", "), len(args)-1, len(args), projectColumns)
+
+	p, err := scanProject(r.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return p, myerr.ErrProjectNotFound
+		}
+
+		if isUniqueViolation(err) {
+			return p, myerr.ErrProjectKeyTaken
+		}
+
+		return p, errors.Wrap(err, "failed to update project")
+	}
+
+	return p, nil
+}
+
+func (r *repPostgres) DeleteProject(ctx context.Context, owner string, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// после блокировки новые задачи в проект не попадут: taskProject ждёт FOR SHARE
+	if err = tx.QueryRow(ctx, lockProjectForDelete, id, owner).Scan(&id); err != nil {
+		if err == pgx.ErrNoRows {
+			return myerr.ErrProjectNotFound
+		}
+
+		return errors.Wrap(err, "failed to delete project")
+	}
+
+	var hasTasks bool
+	if err = tx.QueryRow(ctx, projectHasTasks, id).Scan(&hasTasks); err != nil {
+		return errors.Wrap(err, "failed to delete project")
+	}
+
+	if hasTasks {
+		return myerr.ErrProjectNotEmpty
+	}
+
+	if _, err = tx.Exec(ctx, deleteProject, id, owner); err != nil {
+		return errors.Wrap(err, "failed to delete project")
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete project")
+}
+
+// taskProject - проект для новой или переносимой задачи: указанный или проект по умолчанию.
+// Строка проекта блокируется до конца транзакции, чтобы его не удалили и не архивировали параллельно.
+func taskProject(ctx context.Context, tx pgx.Tx, owner string, id *uuid.UUID) (uuid.UUID, error) {
+	var projectId uuid.UUID
+	var archived bool
+
+	if id == nil {
+		_, err := tx.Exec(ctx, insertDefaultProject, uuid.New(), owner, DefaultProjectKey, defaultProjectName)
+		if err != nil {
+			return uuid.Nil, errors.Wrap(err, "failed to create default project")
+		}
+
+		err = tx.QueryRow(ctx, lockProjectByKey, owner, DefaultProjectKey).Scan(&projectId, &archived)
+		if err != nil {
+			return uuid.Nil, errors.Wrap(err, "failed to query default project")
+		}
+	} else {
+		projectId = *id
+
+		if err := tx.QueryRow(ctx, lockProject, projectId, owner).Scan(&archived); err != nil {
+			if err == pgx.ErrNoRows {
+				return uuid.Nil, myerr.ErrProjectNotFound
+			}
+
+			return uuid.Nil, errors.Wrap(err, "failed to query project")
+		}
+	}
+
+	if archived {
+		return uuid.Nil, myerr.ErrProjectArchived
+	}
+
+	return projectId, nil
+}
@@ -0,0 +1,73 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	copyTaskAssignees = `INSERT INTO task_assignees (task_id, assignee) SELECT $2, assignee FROM task_assignees WHERE task_id = $1;`
+	setNextOccurrence = `UPDATE tasks SET recurrence_next_id = $2 WHERE id = $1;`
+)
+
+// recurrenceArgs - значения колонок recurrence_*, NULL для задачи без повторения
+func recurrenceArgs(recurrence *Recurrence) (*string, *string, *time.Time, *int) {
+	if recurrence == nil {
+		return nil, nil, nil, nil
+	}
+
+	return &recurrence.Rule, &recurrence.TZ, &recurrence.Start, &recurrence.Seq
+}
+
+// spawnNextOccurrence - создаёт следующее повторение выполненной задачи в той же транзакции
+func spawnNextOccurrence(ctx context.Context, tx pgx.Tx, task Task, status string) (Task, error) {
+	next, ok := nextOccurrence(task, status)
+	if !ok {
+		return task, nil
+	}
+
+	rule, tz, start, seq := recurrenceArgs(next.Recurrence)
+	_, err := tx.Exec(ctx, insertTaskQuery, next.Id, next.OwnerId, next.Title, next.Description, next.Status,
+		next.DueAt, next.ParentId, next.ProjectId, rule, tz, start, seq, next.Points, next.Minutes, next.Fields)
+	if err != nil {
+		return task, errors.Wrap(err, "failed to insert next occurrence")
+	}
+
+	if err = setTaskTags(ctx, tx, next.Id, next.Tags); err != nil {
+		return task, err
+	}
+
+	if _, err = tx.Exec(ctx, copyTaskAssignees, task.Id, next.Id); err != nil {
+		return task, errors.Wrap(err, "failed to copy assignees")
+	}
+
+	if _, err = tx.Exec(ctx, copyTaskChecklist, task.Id, next.Id); err != nil {
+		return task, errors.Wrap(err, "failed to copy checklist")
+	}
+
+	created, err := scanTask(tx.QueryRow(ctx, selectTasksQuery, next.Id, next.OwnerId))
+	if err != nil {
+		return task, errors.Wrap(err, "failed to query next occurrence")
+	}
+
+	if err = addRevision(ctx, tx, next.OwnerId, created); err != nil {
+		return task, err
+	}
+
+	if err = appendAudit(ctx, tx, next.OwnerId, AuditCreate, next.Id, nil, created); err != nil {
+		return task, err
+	}
+
+	if _, err = tx.Exec(ctx, setNextOccurrence, task.Id, next.Id); err != nil {
+		return task, errors.Wrap(err, "failed to link next occurrence")
+	}
+
+	recurrence := *task.Recurrence
+	recurrence.NextId = &next.Id
+	task.Recurrence = &recurrence
+
+	return task, nil
+}
@@ -0,0 +1,63 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	insertTags     = `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING;`
+	insertTaskTags = `INSERT INTO task_tags (task_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2);`
+	deleteTaskTags = `DELETE FROM task_tags WHERE task_id = $1;`
+	selectTagCount = `SELECT g.name, count(*) FROM task_tags tt
+		JOIN tags g ON g.id = tt.tag_id
+		JOIN tasks t ON t.id = tt.task_id
+		WHERE t.owner_id = $1 AND t.deleted_at IS NULL
+		GROUP BY g.name
+		ORDER BY count(*) DESC, g.name;`
+)
+
+// setTaskTags - привязка тегов к задаче, новые теги создаются
+func setTaskTags(ctx context.Context, tx pgx.Tx, id uuid.UUID, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tags = NormalizeTags(tags)
+
+	if _, err := tx.Exec(ctx, insertTags, tags); err != nil {
+		return errors.Wrap(err, "failed to insert tags")
+	}
+
+	if _, err := tx.Exec(ctx, insertTaskTags, id, tags); err != nil {
+		return errors.Wrap(err, "failed to insert task tags")
+	}
+
+	return nil
+}
+
+func (r *repPostgres) TagCounts(ctx context.Context, owner string) ([]TagCount, error) {
+	counts := make([]TagCount, 0)
+
+	rows, err := r.pool.Query(ctx, selectTagCount, owner)
+	if err != nil {
+		return counts, errors.Wrap(err, "failed to count tags")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c TagCount
+		if err = rows.Scan(&c.Tag, &c.Count); err != nil {
+			return counts, errors.Wrap(err, "failed to count tags")
+		}
+		counts = append(counts, c)
+	}
+	if err = rows.Err(); err != nil {
+		return counts, errors.Wrap(err, "failed to count tags")
+	}
+
+	return counts, nil
+}
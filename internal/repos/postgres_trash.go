@@ -0,0 +1,203 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	selectTrash = `SELECT ` + taskColumns + ` FROM tasks WHERE owner_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3;`
+	restoreTask = `UPDATE tasks SET deleted_at = NULL, updated_at = now()
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL
+		RETURNING ` + taskColumns + `;`
+	// строки блокируются, чтобы восстановление не пересеклось с удалением
+	lockTrashTask          = `SELECT id FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL FOR UPDATE;`
+	lockOldTrash           = `SELECT id FROM tasks WHERE deleted_at < $1 ORDER BY deleted_at, id FOR UPDATE;`
+	selectPurgeAttachments = `SELECT ` + attachmentColumns + ` FROM task_attachments a
+		WHERE a.task_id = ANY($1) ORDER BY a.created_at, a.id;`
+	// комментарии, вложения, зависимости и ревизии удаляются каскадом, ссылки обнуляются
+	purgeTasks = `DELETE FROM tasks WHERE id = ANY($1) RETURNING ` + taskColumns + `;`
+)
+
+func (r *repPostgres) ListTrash(ctx context.Context, owner string, page, limit int) ([]Task, error) {
+	rows, err := r.pool.Query(ctx, selectTrash, owner, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list trash")
+	}
+	defer rows.Close()
+
+	tasks := make([]Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return tasks, errors.Wrap(err, "failed to list trash")
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return tasks, errors.Wrap(err, "failed to list trash")
+	}
+
+	if len(tasks) == 0 && page > 1 {
+		return tasks, myerr.ErrRange
+	}
+
+	return tasks, nil
+}
+
+func (r *repPostgres) RestoreTask(ctx context.Context, owner string, id uuid.UUID) (Task, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Task{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	task, err := scanTask(tx.QueryRow(ctx, restoreTask, id, owner))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return task, myerr.ErrTaskNotFound
+		}
+
+		return task, errors.Wrap(err, "failed to restore task")
+	}
+
+	if err = addRevision(ctx, tx, owner, task); err != nil {
+		return task, err
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditRestore, id, nil, task); err != nil {
+		return task, err
+	}
+
+	return task, errors.Wrap(tx.Commit(ctx), "failed to restore task")
+}
+
+func (r *repPostgres) PurgeTask(ctx context.Context, owner string, id uuid.UUID) (Task, []Attachment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Task{}, nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := lockIds(ctx, tx, lockTrashTask, id, owner)
+	if err != nil {
+		return Task{}, nil, errors.Wrap(err, "failed to purge task")
+	}
+
+	if len(ids) == 0 {
+		return Task{}, nil, myerr.ErrTaskNotFound
+	}
+
+	tasks, files, err := purgeLocked(ctx, tx, owner, ids)
+	if err != nil || len(tasks) == 0 {
+		return Task{}, nil, errors.Wrap(err, "failed to purge task")
+	}
+
+	return tasks[0], files, errors.Wrap(tx.Commit(ctx), "failed to purge task")
+}
+
+func (r *repPostgres) PurgeTrash(ctx context.Context, before time.Time) ([]Task, []Attachment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := lockIds(ctx, tx, lockOldTrash, before)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to purge trash")
+	}
+
+	if len(ids) == 0 {
+		return []Task{}, []Attachment{}, nil
+	}
+
+	tasks, files, err := purgeLocked(ctx, tx, SystemActor, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tasks, files, errors.Wrap(tx.Commit(ctx), "failed to purge trash")
+}
+
+func lockIds(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// purgeLocked - окончательное удаление заблокированных задач от имени actor; вложения возвращаются для удаления файлов.
+// Задачи возвращаются и попадают в журнал в порядке ids, RETURNING порядок не гарантирует
+func purgeLocked(ctx context.Context, tx pgx.Tx, actor string, ids []uuid.UUID) ([]Task, []Attachment, error) {
+	rows, err := tx.Query(ctx, selectPurgeAttachments, ids)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to query attachments")
+	}
+
+	files := make([]Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, nil, errors.Wrap(err, "failed to query attachments")
+		}
+		files = append(files, a)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to query attachments")
+	}
+
+	rows, err = tx.Query(ctx, purgeTasks, ids)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to purge tasks")
+	}
+
+	deleted, err := collectTasks(rows)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to purge tasks")
+	}
+
+	byId := make(map[uuid.UUID]Task, len(deleted))
+	for _, task := range deleted {
+		byId[task.Id] = task
+	}
+
+	tasks := make([]Task, 0, len(deleted))
+	for _, id := range ids {
+		task, ok := byId[id]
+		if !ok {
+			continue
+		}
+
+		if err = appendAudit(ctx, tx, actor, AuditPurge, task.Id, task, nil); err != nil {
+			return nil, nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, files, nil
+}
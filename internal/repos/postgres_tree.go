@@ -0,0 +1,139 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	// Изменения иерархии одного владельца выполняются по очереди, иначе два переноса могут дать цикл
+	lockTaskTreeQuery = `SELECT pg_advisory_xact_lock(hashtext('task_tree:' || $1));`
+	taskExistsQuery   = `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL);`
+	// Есть ли id среди предков parent (включая сам parent); предки в корзине тоже считаются
+	taskCycleQuery = `WITH RECURSIVE up AS (
+			SELECT id, parent_id FROM tasks WHERE id = $1
+			UNION
+			SELECT t.id, t.parent_id FROM tasks t JOIN up ON t.id = up.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM up WHERE id = $2);`
+	openChildrenQuery = `SELECT EXISTS (SELECT 1 FROM tasks WHERE parent_id = $1 AND status <> ALL($2) AND deleted_at IS NULL);`
+	selectChildren    = `SELECT ` + taskColumns + ` FROM tasks WHERE parent_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		ORDER BY created_at, id;`
+	selectDescendants = `WITH RECURSIVE down AS (
+			SELECT id FROM tasks WHERE parent_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN down ON t.parent_id = down.id WHERE t.deleted_at IS NULL
+		)
+		SELECT ` + taskColumns + ` FROM tasks WHERE id IN (SELECT id FROM down) ORDER BY created_at, id;`
+)
+
+func lockTaskTree(ctx context.Context, tx pgx.Tx, owner string) error {
+	if _, err := tx.Exec(ctx, lockTaskTreeQuery, owner); err != nil {
+		return errors.Wrap(err, "failed to lock task tree")
+	}
+
+	return nil
+}
+
+func checkParent(ctx context.Context, tx pgx.Tx, owner string, parent uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, taskExistsQuery, parent, owner).Scan(&exists); err != nil {
+		return errors.Wrap(err, "failed to query parent task")
+	}
+
+	if !exists {
+		return myerr.ErrParentNotFound
+	}
+
+	return nil
+}
+
+// checkHierarchy - проверки иерархии перед изменением задачи: цикл при переносе и незакрытые подзадачи
+func (r *repPostgres) checkHierarchy(ctx context.Context, tx pgx.Tx, owner string, id uuid.UUID, task UpdateTask) error {
+	done := r.workflow.IsDone(task.Status)
+	if task.ParentId == nil && !done {
+		return nil
+	}
+
+	if err := lockTaskTree(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	// чужая задача не должна выдавать себя ошибками иерархии
+	var exists bool
+	if err := tx.QueryRow(ctx, taskExistsQuery, id, owner).Scan(&exists); err != nil {
+		return errors.Wrap(err, "failed to query task")
+	}
+
+	if !exists {
+		return myerr.ErrTaskNotFound
+	}
+
+	if task.ParentId != nil {
+		if err := checkParent(ctx, tx, owner, *task.ParentId); err != nil {
+			return err
+		}
+
+		var cycle bool
+		if err := tx.QueryRow(ctx, taskCycleQuery, *task.ParentId, id).Scan(&cycle); err != nil {
+			return errors.Wrap(err, "failed to check task hierarchy")
+		}
+
+		if cycle {
+			return myerr.ErrTaskCycle
+		}
+	}
+
+	if done {
+		var open bool
+		if err := tx.QueryRow(ctx, openChildrenQuery, id, r.workflow.Done()).Scan(&open); err != nil {
+			return errors.Wrap(err, "failed to check subtasks")
+		}
+
+		if open {
+			return myerr.ErrOpenSubtasks
+		}
+	}
+
+	return nil
+}
+
+func (r *repPostgres) GetChildren(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	return r.queryTree(ctx, selectChildren, owner, id, "failed to get children")
+}
+
+func (r *repPostgres) GetDescendants(ctx context.Context, owner string, id uuid.UUID) ([]Task, error) {
+	return r.queryTree(ctx, selectDescendants, owner, id, "failed to get descendants")
+}
+
+func (r *repPostgres) queryTree(ctx context.Context, query, owner string, id uuid.UUID, msg string) ([]Task, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, id, owner).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, msg)
+	}
+
+	if !exists {
+		return nil, myerr.ErrTaskNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, query, id, owner)
+	if err != nil {
+		return nil, errors.Wrap(err, msg)
+	}
+
+	tasks, err := collectTasks(rows)
+	if err != nil {
+		return tasks, errors.Wrap(err, msg)
+	}
+
+	if tasks == nil {
+		tasks = []Task{}
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,319 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+const (
+	// длительность в целых секундах, как и в хранилище в памяти
+	worklogSeconds = `FLOOR(EXTRACT(EPOCH FROM w.ended_at - w.started_at))`
+	worklogColumns = `w.id, w.task_id, w.user_id, w.started_at, w.ended_at, w.note, w.created_at,
+		COALESCE(` + worklogSeconds + `, 0)::bigint`
+	// второй запущенный таймер пользователя не даст вставить уникальный индекс idx_task_worklogs_running
+	insertTimer = `INSERT INTO task_worklogs AS w (id, task_id, user_id, started_at, note)
+		SELECT $1::uuid, id, $3::text, now(), $4::text FROM tasks
+		WHERE id = $2 AND owner_id = $5 AND deleted_at IS NULL
+		RETURNING ` + worklogColumns + `;`
+	selectRunningTimer = `SELECT ` + worklogColumns + ` FROM task_worklogs w JOIN tasks t ON t.id = w.task_id
+		WHERE w.task_id = $1 AND w.user_id = $3 AND w.ended_at IS NULL AND t.owner_id = $2 AND t.deleted_at IS NULL
+		FOR UPDATE OF w;`
+	stopTimer = `UPDATE task_worklogs w SET ended_at = GREATEST(now(), w.started_at),
+			note = CASE WHEN $4::text = '' THEN w.note ELSE $4::text END
+		FROM tasks t
+		WHERE w.task_id = $1 AND w.user_id = $3 AND w.ended_at IS NULL
+			AND t.id = w.task_id AND t.owner_id = $2 AND t.deleted_at IS NULL
+		RETURNING ` + worklogColumns + `;`
+	insertWorklog = `INSERT INTO task_worklogs AS w (id, task_id, user_id, started_at, ended_at, note)
+		SELECT $1::uuid, id, $3::text, $4::timestamptz, $5::timestamptz, $6::text FROM tasks
+		WHERE id = $2 AND owner_id = $7 AND deleted_at IS NULL
+		RETURNING ` + worklogColumns + `;`
+	selectWorklogs = `SELECT ` + worklogColumns + ` FROM task_worklogs w WHERE w.task_id = $1
+		ORDER BY w.started_at, w.id LIMIT $2 OFFSET $3;`
+	selectWorklogTotals = `SELECT w.user_id, COALESCE(SUM(` + worklogSeconds + `), 0)::bigint FROM task_worklogs w
+		WHERE w.task_id = $1 GROUP BY w.user_id ORDER BY w.user_id;`
+	selectWorklogForUpdate = `SELECT ` + worklogColumns + ` FROM task_worklogs w JOIN tasks t ON t.id = w.task_id
+		WHERE w.id = $1 AND w.task_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL FOR UPDATE OF w;`
+	updateWorklog = `UPDATE task_worklogs w SET started_at = $2, ended_at = $3, note = $4 WHERE w.id = $1
+		RETURNING ` + worklogColumns + `;`
+	deleteWorklog   = `DELETE FROM task_worklogs WHERE id = $1;`
+	stopTaskTimers  = `UPDATE task_worklogs SET ended_at = GREATEST(now(), started_at) WHERE task_id = $1 AND ended_at IS NULL;`
+	selectTaskTimes = `SELECT t.id, t.title,
+			SUM(FLOOR(EXTRACT(EPOCH FROM LEAST(w.ended_at, $3) - GREATEST(w.started_at, $2))))::bigint AS spent
+		FROM task_worklogs w JOIN tasks t ON t.id = w.task_id
+		WHERE t.owner_id = $1 AND t.deleted_at IS NULL AND w.ended_at > $2 AND w.started_at < $3
+		GROUP BY t.id, t.title
+		HAVING SUM(FLOOR(EXTRACT(EPOCH FROM LEAST(w.ended_at, $3) - GREATEST(w.started_at, $2)))) > 0
+		ORDER BY spent DESC, t.id;`
+)
+
+func scanWorklog(row pgx.Row) (Worklog, error) {
+	var w Worklog
+	err := row.Scan(&w.Id, &w.TaskId, &w.User, &w.Start, &w.End, &w.Note, &w.CreatedAt, &w.Duration)
+
+	return w, err
+}
+
+func (r *repPostgres) StartTimer(ctx context.Context, owner string, worklog Worklog) (Worklog, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	w, err := scanWorklog(tx.QueryRow(ctx, insertTimer, worklog.Id, worklog.TaskId, worklog.User, worklog.Note, owner))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return w, myerr.ErrTaskNotFound
+		}
+
+		if isUniqueViolation(err) {
+			return w, myerr.ErrTimerRunning
+		}
+
+		return w, errors.Wrap(err, "failed to start timer")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditWorklogAdd, w.TaskId, nil, w); err != nil {
+		return w, err
+	}
+
+	return w, errors.Wrap(tx.Commit(ctx), "failed to start timer")
+}
+
+func (r *repPostgres) StopTimer(ctx context.Context, owner string, taskId uuid.UUID, user, note string) (Worklog, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := scanWorklog(tx.QueryRow(ctx, selectRunningTimer, taskId, owner, user))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err = tx.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+			return Worklog{}, errors.Wrap(err, "failed to query task")
+		}
+
+		if !exists {
+			return Worklog{}, myerr.ErrTaskNotFound
+		}
+
+		return Worklog{}, myerr.ErrNoTimer
+	}
+
+	if err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to stop timer")
+	}
+
+	w, err := scanWorklog(tx.QueryRow(ctx, stopTimer, taskId, owner, user, note))
+	if err != nil {
+		return w, errors.Wrap(err, "failed to stop timer")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditWorklogUpdate, taskId, before, w); err != nil {
+		return w, err
+	}
+
+	return w, errors.Wrap(tx.Commit(ctx), "failed to stop timer")
+}
+
+func (r *repPostgres) AddWorklog(ctx context.Context, owner string, worklog Worklog) (Worklog, error) {
+	if worklog.End == nil || !worklog.End.After(worklog.Start) {
+		return Worklog{}, myerr.ErrWorklogRange
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	w, err := scanWorklog(tx.QueryRow(ctx, insertWorklog,
+		worklog.Id, worklog.TaskId, worklog.User, worklog.Start, worklog.End, worklog.Note, owner))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return w, myerr.ErrTaskNotFound
+		}
+
+		return w, errors.Wrap(err, "failed to add worklog")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditWorklogAdd, w.TaskId, nil, w); err != nil {
+		return w, err
+	}
+
+	return w, errors.Wrap(tx.Commit(ctx), "failed to add worklog")
+}
+
+func (r *repPostgres) ListWorklogs(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Worklog, []UserTime, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list worklogs")
+	}
+
+	if !exists {
+		return nil, nil, myerr.ErrTaskNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, selectWorklogs, taskId, limit, (page-1)*limit)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list worklogs")
+	}
+	defer rows.Close()
+
+	worklogs := make([]Worklog, 0)
+	for rows.Next() {
+		w, err := scanWorklog(rows)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to list worklogs")
+		}
+		worklogs = append(worklogs, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list worklogs")
+	}
+
+	rows, err = r.pool.Query(ctx, selectWorklogTotals, taskId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to sum worklogs")
+	}
+	defer rows.Close()
+
+	totals := make([]UserTime, 0)
+	for rows.Next() {
+		var t UserTime
+		if err = rows.Scan(&t.User, &t.Duration); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to sum worklogs")
+		}
+		totals = append(totals, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to sum worklogs")
+	}
+
+	if len(worklogs) == 0 && page > 1 {
+		return worklogs, totals, myerr.ErrRange
+	}
+
+	return worklogs, totals, nil
+}
+
+func (r *repPostgres) UpdateWorklog(ctx context.Context, owner string, taskId, id uuid.UUID, change WorklogUpdate) (Worklog, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	w, err := lockWorklog(ctx, tx, owner, taskId, id)
+	if err != nil {
+		return Worklog{}, err
+	}
+
+	before := w
+
+	if change.Start != nil {
+		w.Start = *change.Start
+	}
+
+	if change.End != nil {
+		w.End = change.End
+	}
+
+	if change.Note != nil {
+		w.Note = *change.Note
+	}
+
+	if w.End != nil && !w.End.After(w.Start) {
+		return Worklog{}, myerr.ErrWorklogRange
+	}
+
+	w, err = scanWorklog(tx.QueryRow(ctx, updateWorklog, id, w.Start, w.End, w.Note))
+	if err != nil {
+		return w, errors.Wrap(err, "failed to update worklog")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditWorklogUpdate, taskId, before, w); err != nil {
+		return w, err
+	}
+
+	return w, errors.Wrap(tx.Commit(ctx), "failed to update worklog")
+}
+
+func (r *repPostgres) DeleteWorklog(ctx context.Context, owner string, taskId, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockWorklog(ctx, tx, owner, taskId, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, deleteWorklog, id); err != nil {
+		return errors.Wrap(err, "failed to delete worklog")
+	}
+
+	if err = appendAudit(ctx, tx, owner, AuditWorklogDelete, taskId, before, nil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "failed to delete worklog")
+}
+
+func (r *repPostgres) WorklogReport(ctx context.Context, owner string, from, to time.Time) ([]TaskTime, error) {
+	rows, err := r.pool.Query(ctx, selectTaskTimes, owner, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build worklog report")
+	}
+	defer rows.Close()
+
+	report := make([]TaskTime, 0)
+	for rows.Next() {
+		var t TaskTime
+		if err = rows.Scan(&t.TaskId, &t.Title, &t.Duration); err != nil {
+			return nil, errors.Wrap(err, "failed to build worklog report")
+		}
+		report = append(report, t)
+	}
+
+	return report, errors.Wrap(rows.Err(), "failed to build worklog report")
+}
+
+// lockWorklog - запись времени по задаче владельца, которую может менять только автор; строка блокируется до конца транзакции
+func lockWorklog(ctx context.Context, tx pgx.Tx, owner string, taskId, id uuid.UUID) (Worklog, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, taskExistsQuery, taskId, owner).Scan(&exists); err != nil {
+		return Worklog{}, errors.Wrap(err, "failed to query task")
+	}
+
+	if !exists {
+		return Worklog{}, myerr.ErrTaskNotFound
+	}
+
+	w, err := scanWorklog(tx.QueryRow(ctx, selectWorklogForUpdate, id, taskId, owner))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return w, myerr.ErrWorklogNotFound
+		}
+
+		return w, errors.Wrap(err, "failed to query worklog")
+	}
+
+	if w.User != owner {
+		return w, myerr.ErrWorklogAuthor
+	}
+
+	return w, nil
+}
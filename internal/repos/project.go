@@ -0,0 +1,39 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultProjectKey - ключ проекта, в который попадают задачи без явного проекта.
+// Проект создаётся при первой такой задаче владельца.
+const DefaultProjectKey = "DEFAULT"
+
+const defaultProjectName = "Default"
+
+// Project - контейнер задач владельца; ключ уникален в пределах владельца
+type Project struct {
+	Id          uuid.UUID `json:"id"`
+	OwnerId     string    `json:"owner_id"`
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Archived    bool      `json:"archived"` // в архивный проект нельзя добавлять задачи
+	CreatedAt   time.Time `json:"created"`
+	UpdatedAt   time.Time `json:"updated"`
+}
+
+type UpdateProject struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Archived    *bool  `json:"archived"`
+}
+
+// ProjectFilter - постраничная выборка проектов, Archived = nil - все проекты
+type ProjectFilter struct {
+	Page     int
+	Limit    int
+	Archived *bool
+}
@@ -0,0 +1,67 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/volkowlad/week4/internal/recur"
+)
+
+// Recurrence - повторение задачи по правилу RRULE; каждое повторение - отдельная задача
+type Recurrence struct {
+	Rule   string     `json:"rule"`              // каноническая запись, см. recur.Parse
+	TZ     string     `json:"tz"`                // часовой пояс IANA, в котором считаются даты
+	Start  time.Time  `json:"start"`             // срок первого повторения серии
+	Seq    int        `json:"seq"`               // номер повторения, с 1
+	NextId *uuid.UUID `json:"next_id,omitempty"` // следующее повторение, если уже создано
+}
+
+// nextOccurrence - следующее повторение выполненной задачи: копия в статусе status без времени
+// создания, со сроком по правилу. ok = false, если повторения закончились.
+func nextOccurrence(t Task, status string) (Task, bool) {
+	if t.Recurrence == nil || t.Recurrence.NextId != nil || t.DueAt == nil {
+		return Task{}, false
+	}
+
+	rule, err := recur.Parse(t.Recurrence.Rule)
+	if err != nil {
+		return Task{}, false
+	}
+
+	loc, err := time.LoadLocation(t.Recurrence.TZ)
+	if err != nil {
+		return Task{}, false
+	}
+
+	due, ok := rule.Next(t.Recurrence.Start, *t.DueAt, t.Recurrence.Seq, loc)
+	if !ok {
+		return Task{}, false
+	}
+
+	due = due.UTC()
+	checklist := resetChecklist(t.Checklist)
+
+	return Task{
+		Id:          uuid.New(),
+		OwnerId:     t.OwnerId,
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      status,
+		DueAt:       &due,
+		Tags:        append([]string{}, t.Tags...),
+		Assignees:   append([]string{}, t.Assignees...),
+		ParentId:    t.ParentId,
+		ProjectId:   t.ProjectId,
+		Estimate:    t.Estimate,
+		Fields:      t.Fields,
+		Checklist:   checklist,
+		Progress:    progressOf(checklist),
+		Recurrence: &Recurrence{
+			Rule:  t.Recurrence.Rule,
+			TZ:    t.Recurrence.TZ,
+			Start: t.Recurrence.Start,
+			Seq:   t.Recurrence.Seq + 1,
+		},
+	}, true
+}
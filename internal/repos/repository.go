@@ -2,14 +2,146 @@ package repos
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Repository - хранилище задач. Все методы принимают владельца (вызывающего):
+// чужая задача для него выглядит так же, как несуществующая (myerr.ErrTaskNotFound)
 type Repository interface {
-	CreateTask(ctx context.Context, task TaskCreate) error // Создание задачи
-	GetTask(ctx context.Context, id uuid.UUID) (Task, error)
-	GetAllTasks(ctx context.Context, page, limit int) ([]Task, error)
-	DeleteTask(ctx context.Context, id uuid.UUID) error
-	UpdateTask(ctx context.Context, task UpdateTask, id uuid.UUID) (Task, error)
+	CreateTask(ctx context.Context, owner string, task TaskCreate) error // Создание задачи
+	GetTask(ctx context.Context, owner string, id uuid.UUID) (Task, error)
+	GetAllTasks(ctx context.Context, owner string, filter TaskFilter) ([]Task, error)
+	DeleteTask(ctx context.Context, owner string, id uuid.UUID) error // переносит задачу в корзину
+	UpdateTask(ctx context.Context, owner string, task UpdateTask, id uuid.UUID) (Task, error)
+	FlagOverdueTasks(ctx context.Context, now time.Time) ([]Task, error) // помечает просроченные задачи всех владельцев, возвращает помеченные сейчас
+	TagCounts(ctx context.Context, owner string) ([]TagCount, error)
+	GetChildren(ctx context.Context, owner string, id uuid.UUID) ([]Task, error)
+	GetDescendants(ctx context.Context, owner string, id uuid.UUID) ([]Task, error)              // все подзадачи на любой глубине
+	AssignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error)   // повторное назначение не ошибка
+	UnassignTask(ctx context.Context, owner string, id uuid.UUID, assignee string) (Task, error) // myerr.ErrNotAssigned, если не назначен
+
+	ProjectRepository
+	DependencyRepository
+	CommentRepository
+	AttachmentRepository
+	APIKeyRepository
+	AuditRepository
+	RevisionRepository
+	TrashRepository
+	ArchiveRepository
+	WorklogRepository
+	ChecklistRepository
+	FieldRepository
+}
+
+// ArchiveRepository - архив выполненных задач. Архивная задача доступна по id и меняется как обычно,
+// но в списки попадает только с фильтром Archived; уход из выполненного статуса возвращает её из архива.
+type ArchiveRepository interface {
+	ArchiveTasks(ctx context.Context, before time.Time) ([]Task, error) // задачи всех владельцев, выполненные раньше before
+	UnarchiveTask(ctx context.Context, owner string, id uuid.UUID) (Task, error)
+}
+
+// TrashRepository - корзина. Задача в корзине не видна обычным чтениям и изменениям, но сохраняет
+// подзадачи, зависимости, комментарии, вложения и историю, поэтому восстанавливается целиком.
+// Окончательное удаление возвращает вложения задач, чтобы вызывающий удалил их содержимое.
+type TrashRepository interface {
+	ListTrash(ctx context.Context, owner string, page, limit int) ([]Task, error) // последние удалённые сверху
+	RestoreTask(ctx context.Context, owner string, id uuid.UUID) (Task, error)
+	PurgeTask(ctx context.Context, owner string, id uuid.UUID) (Task, []Attachment, error) // только задача из корзины
+	PurgeTrash(ctx context.Context, before time.Time) ([]Task, []Attachment, error)        // задачи всех владельцев, удалённые раньше before
+}
+
+// ProjectRepository - проекты владельца. Каждая задача принадлежит проекту,
+// проект с задачами удалить нельзя (myerr.ErrProjectNotEmpty).
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, owner string, project Project) (Project, error)
+	GetProject(ctx context.Context, owner string, id uuid.UUID) (Project, error)
+	ListProjects(ctx context.Context, owner string, filter ProjectFilter) ([]Project, error)
+	UpdateProject(ctx context.Context, owner string, id uuid.UUID, project UpdateProject) (Project, error)
+	DeleteProject(ctx context.Context, owner string, id uuid.UUID) error
+}
+
+// FieldRepository - пользовательские поля задач проекта. Значения проверяются при создании задачи,
+// при их изменении и при переносе задачи в другой проект (myerr.FieldValueError); обязательность
+// проверяется только тогда, поэтому новое обязательное поле не мешает менять остальное в старых задачах.
+// Удаление поля снимает его значения со всех задач проекта.
+type FieldRepository interface {
+	CreateField(ctx context.Context, owner string, field CustomField) (CustomField, error)
+	ListFields(ctx context.Context, owner string, projectId uuid.UUID) ([]CustomField, error)                               // в порядке создания
+	UpdateField(ctx context.Context, owner string, projectId uuid.UUID, key string, field CustomField) (CustomField, error) // ключ и тип не меняются
+	DeleteField(ctx context.Context, owner string, projectId uuid.UUID, key string) error
+}
+
+// DependencyRepository - блокирующие зависимости между задачами одного владельца.
+// Граф зависимостей всегда ацикличен: ребро, дающее цикл, отклоняется с myerr.ErrDependencyCycle.
+type DependencyRepository interface {
+	AddDependency(ctx context.Context, owner string, dep Dependency) error
+	RemoveDependency(ctx context.Context, owner string, dep Dependency) error
+	GetBlockers(ctx context.Context, owner string, id uuid.UUID) ([]Task, error)
+	DependencyGraph(ctx context.Context, owner string) ([]Task, []Dependency, error) // все задачи владельца и все рёбра
+}
+
+// WorklogRepository - учёт времени по задачам. У пользователя не больше одного запущенного таймера
+// (myerr.ErrTimerRunning), менять и удалять запись может только её автор (myerr.ErrWorklogAuthor).
+// Итоги и отчёт считают только завершённые записи.
+type WorklogRepository interface {
+	StartTimer(ctx context.Context, owner string, worklog Worklog) (Worklog, error) // Start выставляет хранилище
+	StopTimer(ctx context.Context, owner string, taskId uuid.UUID, user, note string) (Worklog, error)
+	AddWorklog(ctx context.Context, owner string, worklog Worklog) (Worklog, error)
+	ListWorklogs(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Worklog, []UserTime, error)
+	UpdateWorklog(ctx context.Context, owner string, taskId, id uuid.UUID, change WorklogUpdate) (Worklog, error)
+	DeleteWorklog(ctx context.Context, owner string, taskId, id uuid.UUID) error
+	WorklogReport(ctx context.Context, owner string, from, to time.Time) ([]TaskTime, error) // время записей внутри [from, to)
+}
+
+// ChecklistRepository - упорядоченный чек-лист задачи. Изменения возвращают весь чек-лист
+// с позициями 1..n и меняют updated задачи; изменения одного чек-листа не пересекаются.
+type ChecklistRepository interface {
+	ListChecklist(ctx context.Context, owner string, taskId uuid.UUID) ([]ChecklistItem, error)
+	AddChecklistItem(ctx context.Context, owner string, taskId uuid.UUID, item ChecklistItem) ([]ChecklistItem, error) // в конец
+	MoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, position int) ([]ChecklistItem, error)
+	ToggleChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID, done *bool) ([]ChecklistItem, error) // nil - переключить
+	RemoveChecklistItem(ctx context.Context, owner string, taskId, id uuid.UUID) ([]ChecklistItem, error)
+}
+
+// CommentRepository - комментарии к задачам. Комментарии удаляются вместе с задачей,
+// изменить или удалить комментарий может только его автор (myerr.ErrCommentAuthor).
+type CommentRepository interface {
+	AddComment(ctx context.Context, owner string, comment Comment) (Comment, error) // время выставляет хранилище
+	ListComments(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Comment, error)
+	UpdateComment(ctx context.Context, owner string, taskId, id uuid.UUID, body string) (Comment, error)
+	DeleteComment(ctx context.Context, owner string, taskId, id uuid.UUID) error
+}
+
+// AttachmentRepository - метаданные вложений задач, удаляются вместе с задачей
+type AttachmentRepository interface {
+	AddAttachment(ctx context.Context, owner string, a Attachment) (Attachment, error) // время выставляет хранилище
+	ListAttachments(ctx context.Context, owner string, taskId uuid.UUID) ([]Attachment, error)
+	GetAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) (Attachment, error)
+	DeleteAttachment(ctx context.Context, owner string, taskId, id uuid.UUID) error
+}
+
+// RevisionRepository - история задачи. Ревизию записывает само хранилище при каждом изменении задачи
+// (создание, изменение, назначение исполнителей), удаляется история вместе с задачей.
+type RevisionRepository interface {
+	ListRevisions(ctx context.Context, owner string, taskId uuid.UUID, page, limit int) ([]Revision, error)
+	GetRevision(ctx context.Context, owner string, taskId uuid.UUID, rev int) (Revision, error) // myerr.ErrNoRevision
+}
+
+// APIKeyRepository - хранилище API-ключей
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error // обновление last_used_at
+}
+
+// AuditRepository - журнал аудита изменений задач. Записи добавляет само хранилище
+// в той же транзакции, что и изменение, поэтому журнал только читается.
+type AuditRepository interface {
+	ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+	AuditChain(ctx context.Context) ([]AuditEntry, error) // весь журнал по порядку для проверки цепочки
 }
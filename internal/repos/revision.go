@@ -0,0 +1,16 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision - неизменяемый снимок задачи после очередного изменения
+type Revision struct {
+	TaskId    uuid.UUID `json:"task_id"`
+	Rev       int       `json:"rev"` // номер изменения задачи, с 1
+	Actor     string    `json:"actor"`
+	Task      Task      `json:"snapshot"`
+	CreatedAt time.Time `json:"created"`
+}
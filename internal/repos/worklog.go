@@ -0,0 +1,70 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Worklog - затраченное на задачу время. У запущенного таймера End = nil и Duration = 0
+type Worklog struct {
+	Id        uuid.UUID  `json:"id"`
+	TaskId    uuid.UUID  `json:"task_id"`
+	User      string     `json:"user"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	Duration  int64      `json:"duration"` // секунды
+	Note      string     `json:"note"`
+	CreatedAt time.Time  `json:"created"`
+}
+
+// WorklogUpdate - изменение записи, nil - поле не меняется; заданный End останавливает таймер
+type WorklogUpdate struct {
+	Start *time.Time
+	End   *time.Time
+	Note  *string
+}
+
+// UserTime - итог времени по пользователю
+type UserTime struct {
+	User     string `json:"user"`
+	Duration int64  `json:"duration"` // секунды
+}
+
+// TaskTime - итог времени по задаче за период
+type TaskTime struct {
+	TaskId   uuid.UUID `json:"task_id"`
+	Title    string    `json:"title"`
+	Duration int64     `json:"duration"` // секунды
+}
+
+// seconds - длительность записи, у запущенного таймера 0
+func (w Worklog) seconds() int64 {
+	if w.End == nil {
+		return 0
+	}
+
+	return int64(w.End.Sub(w.Start) / time.Second)
+}
+
+// overlap - сколько секунд записи приходится на [from, to); запущенный таймер не учитывается
+func (w Worklog) overlap(from, to time.Time) int64 {
+	if w.End == nil {
+		return 0
+	}
+
+	start, end := w.Start, *w.End
+	if start.Before(from) {
+		start = from
+	}
+
+	if end.After(to) {
+		end = to
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+
+	return int64(end.Sub(start) / time.Second)
+}
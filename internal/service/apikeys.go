@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+func (s *service) CreateAPIKey(ctx *fiber.Ctx) error {
+	issuer, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	var req APIKeyRequest
+
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			return dto.BadResponseError(ctx, dto.FieldIncorrect, "Unknown scope: "+scope)
+		}
+	}
+
+	// ключ всегда выдаётся на себя: задачи разделены по владельцу, и ключ на чужой subject
+	// дал бы доступ к чужим задачам
+	if req.Subject != "" && req.Subject != issuer {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "subject must be the caller issuing the key")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "expires_at must be in the future")
+	}
+
+	plain, hash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		s.log.Error("Failed to generate api key", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}
+	}
+
+	key := repos.APIKey{
+		Id:        uuid.New(),
+		Name:      req.Name,
+		Subject:   issuer,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err = s.repos.CreateAPIKey(ctx.Context(), key); err != nil {
+		s.log.Error("Failed to insert api key", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	s.log.Infow("API key created", "key_id", key.Id, "name", key.Name, "issuer", issuer)
+
+	response := dto.Response{
+		Status: "success",
+		Data:   APIKeyCreatedResponse{Key: plain, APIKey: key},
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (s *service) ListAPIKeys(ctx *fiber.Ctx) error {
+	var keys AllAPIKeysResponse
+	var err error
+
+	keys.APIKeys, err = s.repos.ListAPIKeys(ctx.Context())
+	if err != nil {
+		s.log.Error("Failed to list api keys", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   keys,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) RevokeAPIKey(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	err = s.repos.RevokeAPIKey(ctx.Context(), id)
+	if err != nil {
+		s.log.Error("Failed to revoke api key", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrAPIKeyNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/internal/workflow"
+)
+
+// apiKeysApp - маршруты ключей на хранилище в памяти; вызывающий - subject из заголовка X-Subject
+func apiKeysApp(t *testing.T) (*fiber.App, repos.Repository) {
+	t.Helper()
+
+	var wfCfg config.Workflow
+	if err := envconfig.Process("", &wfCfg); err != nil {
+		t.Fatalf("load workflow config: %v", err)
+	}
+
+	wf, err := workflow.New(wfCfg)
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+
+	rep := repos.NewMemory(wf)
+	s := NewService(rep, nil, config.Attachments{}, wf, zap.NewNop().Sugar())
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		auth.SetIdentity(c, auth.Identity{Subject: c.Get("X-Subject"), Scopes: auth.AllScopes})
+		return c.Next()
+	})
+	app.Post("/keys", s.CreateAPIKey)
+	app.Get("/keys", s.ListAPIKeys)
+	app.Delete("/keys/:id", s.RevokeAPIKey)
+
+	return app, rep
+}
+
+// call - запрос от имени subject, в ответе - статус и поле data
+func call(t *testing.T, app *fiber.App, method, path, subject, body string, data any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-Subject", subject)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	if data != nil && resp.StatusCode < fiber.StatusBadRequest {
+		envelope := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+
+		if err = json.Unmarshal(raw, &envelope); err != nil {
+			t.Fatalf("decode %s: %v", raw, err)
+		}
+
+		if err = json.Unmarshal(envelope.Data, data); err != nil {
+			t.Fatalf("decode data %s: %v", envelope.Data, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func TestCreateAPIKeySubject(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"subject omitted", `{"name":"ci"}`, fiber.StatusCreated},
+		{"own subject", `{"name":"ci","subject":"alice"}`, fiber.StatusCreated},
+		{"other subject", `{"name":"ci","subject":"bob"}`, fiber.StatusBadRequest},
+		{"unknown scope", `{"name":"ci","scopes":["tasks:root"]}`, fiber.StatusBadRequest},
+		{"expired", `{"name":"ci","expires_at":"2020-01-01T00:00:00Z"}`, fiber.StatusBadRequest},
+		{"no name", `{}`, fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _ := apiKeysApp(t)
+
+			var created APIKeyCreatedResponse
+			if code := call(t, app, fiber.MethodPost, "/keys", "alice", tt.body, &created); code != tt.want {
+				t.Fatalf("status %d, want %d", code, tt.want)
+			}
+
+			if tt.want == fiber.StatusCreated && created.APIKey.Subject != "alice" {
+				t.Fatalf("subject %q, want alice", created.APIKey.Subject)
+			}
+		})
+	}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	app, rep := apiKeysApp(t)
+
+	var created APIKeyCreatedResponse
+	if code := call(t, app, fiber.MethodPost, "/keys", "alice", `{"name":"ci","scopes":["tasks:read"]}`, &created); code != fiber.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+
+	if !auth.LooksLikeAPIKey(created.Key) || !strings.HasPrefix(created.Key, created.APIKey.Prefix) {
+		t.Fatalf("created key %q with prefix %q", created.Key, created.APIKey.Prefix)
+	}
+
+	id, err := auth.AuthenticateAPIKey(context.Background(), rep, created.Key)
+	if err != nil || id.Subject != "alice" || !id.Allows(auth.ScopeTasksRead) || id.Allows(auth.ScopeTasksWrite) {
+		t.Fatalf("authenticate created key: %+v, %v", id, err)
+	}
+
+	var list AllAPIKeysResponse
+	if code := call(t, app, fiber.MethodGet, "/keys", "alice", "", &list); code != fiber.StatusOK {
+		t.Fatalf("list: status %d", code)
+	}
+
+	if len(list.APIKeys) != 1 || list.APIKeys[0].Id != created.APIKey.Id || list.APIKeys[0].RevokedAt != nil {
+		t.Fatalf("list: %+v", list.APIKeys)
+	}
+
+	path := "/keys/" + created.APIKey.Id.String()
+	if code := call(t, app, fiber.MethodDelete, path, "alice", "", nil); code != fiber.StatusOK {
+		t.Fatalf("revoke: status %d", code)
+	}
+
+	if _, err = auth.AuthenticateAPIKey(context.Background(), rep, created.Key); err == nil {
+		t.Fatalf("revoked key accepted")
+	}
+
+	if code := call(t, app, fiber.MethodGet, "/keys", "alice", "", &list); code != fiber.StatusOK || list.APIKeys[0].RevokedAt == nil {
+		t.Fatalf("list after revoke: status %d, %+v", code, list.APIKeys)
+	}
+
+	if code := call(t, app, fiber.MethodDelete, "/keys/00000000-0000-0000-0000-000000000001", "alice", "", nil); code != fiber.StatusNotFound {
+		t.Fatalf("revoke unknown: status %d, want %d", code, fiber.StatusNotFound)
+	}
+
+	if code := call(t, app, fiber.MethodDelete, "/keys/bad", "alice", "", nil); code != fiber.StatusBadRequest {
+		t.Fatalf("revoke bad id: status %d, want %d", code, fiber.StatusBadRequest)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// GetArchive - архивные задачи с теми же фильтрами, что и GetAllTasks
+func (s *service) GetArchive(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	projectID, err := parseOptionalID(ctx.Query("project_id"))
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid project_id parameter")
+	}
+
+	return s.listTasks(ctx, owner, projectID, true)
+}
+
+// UnarchiveTask - возвращает задачу в обычные списки
+func (s *service) UnarchiveTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var task TaskResponse
+
+	task.Task, err = s.repos.UnarchiveTask(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to unarchive task", zap.Error(err))
+
+		switch {
+		case errors.Is(err, myerr.ErrTaskNotFound):
+			return dto.NotFound(ctx)
+		case errors.Is(err, myerr.ErrNotArchived):
+			return dto.Conflict(ctx, dto.NotArchived, "Task is not archived")
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+// assigneeMe - псевдоним вызывающего в фильтре и при назначении
+const assigneeMe = "me"
+
+func (s *service) AssignTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req AssigneeRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	return s.changeAssignee(ctx, owner, id, func(task *TaskResponse) error {
+		var err error
+		task.Task, err = s.repos.AssignTask(ctx.Context(), owner, id, assigneeOf(req.Assignee, owner))
+
+		return err
+	})
+}
+
+func (s *service) UnassignTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	assignee := assigneeOf(ctx.Params("assignee"), owner)
+
+	return s.changeAssignee(ctx, owner, id, func(task *TaskResponse) error {
+		var err error
+		task.Task, err = s.repos.UnassignTask(ctx.Context(), owner, id, assignee)
+
+		return err
+	})
+}
+
+// changeAssignee - изменение исполнителей задачи
+func (s *service) changeAssignee(ctx *fiber.Ctx, owner string, id uuid.UUID, change func(task *TaskResponse) error) error {
+	var task TaskResponse
+
+	if err := change(&task); err != nil {
+		s.log.Error("Failed to change assignees", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrNotAssigned) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// assigneeOf - subject исполнителя, "me" заменяется на вызывающего
+func assigneeOf(value, caller string) string {
+	if value == assigneeMe {
+		return caller
+	}
+
+	return value
+}
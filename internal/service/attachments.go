@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/blob"
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+const maxFileName = 255
+
+func (s *service) UploadAttachment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		s.log.Error("Invalid multipart body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Multipart form with a file field expected")
+	}
+
+	if fh.Size > s.limits.MaxSize {
+		return dto.RequestEntityTooLarge(ctx, "File is larger than the allowed size")
+	}
+
+	// задача должна существовать до записи содержимого
+	if _, err = s.repos.GetTask(ctx.Context(), owner, taskID); err != nil {
+		s.log.Error("Failed to get task", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		s.log.Error("Failed to open uploaded file", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		s.log.Error("Failed to read uploaded file", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	contentType, ok := s.fileType(fh.Header.Get(fiber.HeaderContentType), head[:n])
+	if !ok {
+		return dto.UnsupportedMediaType(ctx, "File type is not allowed")
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		s.log.Error("Failed to read uploaded file", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	attachment := repos.Attachment{
+		Id:          uuid.New(),
+		TaskId:      taskID,
+		Name:        fileName(fh.Filename),
+		Size:        fh.Size,
+		ContentType: contentType,
+	}
+
+	hash := sha256.New()
+	if err = s.files.Put(ctx.Context(), attachment.BlobKey(), io.TeeReader(file, hash), fh.Size, contentType); err != nil {
+		s.log.Error("Failed to store attachment", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	var resp AttachmentResponse
+
+	resp.Attachment, err = s.repos.AddAttachment(ctx.Context(), owner, attachment)
+	if err != nil {
+		s.log.Error("Failed to add attachment", zap.Error(err))
+		s.removeBlobs(ctx.Context(), []repos.Attachment{attachment})
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   resp,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (s *service) ListAttachments(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var files AllAttachmentsResponse
+
+	files.Attachments, err = s.repos.ListAttachments(ctx.Context(), owner, taskID)
+	if err != nil {
+		s.log.Error("Failed to list attachments", zap.Error(err))
+		return s.attachmentError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   files,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) DownloadAttachment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, attachmentID, err := attachmentParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	attachment, err := s.repos.GetAttachment(ctx.Context(), owner, taskID, attachmentID)
+	if err != nil {
+		s.log.Error("Failed to get attachment", zap.Error(err))
+		return s.attachmentError(ctx, err)
+	}
+
+	body, err := s.files.Get(ctx.Context(), attachment.BlobKey())
+	if err != nil {
+		s.log.Error("Failed to read attachment", zap.Error(err))
+
+		if errors.Is(err, blob.ErrNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	ctx.Set(fiber.HeaderContentType, attachment.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	ctx.Set("Digest", "sha-256="+attachment.SHA256)
+
+	// Fiber закроет body после отправки
+	return ctx.Status(fiber.StatusOK).SendStream(body, int(attachment.Size))
+}
+
+func (s *service) DeleteAttachment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, attachmentID, err := attachmentParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	attachment, err := s.repos.GetAttachment(ctx.Context(), owner, taskID, attachmentID)
+	if err != nil {
+		s.log.Error("Failed to get attachment", zap.Error(err))
+		return s.attachmentError(ctx, err)
+	}
+
+	if err = s.repos.DeleteAttachment(ctx.Context(), owner, taskID, attachmentID); err != nil {
+		s.log.Error("Failed to delete attachment", zap.Error(err))
+		return s.attachmentError(ctx, err)
+	}
+
+	s.removeBlobs(ctx.Context(), []repos.Attachment{attachment})
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// removeBlobs - удаление содержимого вложений; ошибка только логируется, метаданных уже нет
+func (s *service) removeBlobs(ctx context.Context, files []repos.Attachment) {
+	for _, a := range files {
+		if err := s.files.Delete(ctx, a.BlobKey()); err != nil {
+			s.log.Errorw("Failed to delete attachment blob", "key", a.BlobKey(), "error", err)
+		}
+	}
+}
+
+// fileType - тип файла для сохранения. Заявленный клиентом тип берётся, если он задан,
+// но и он, и тип, определённый по содержимому, должны быть разрешены - так под видом
+// картинки не загрузить исполняемый файл.
+func (s *service) fileType(declared string, head []byte) (string, bool) {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+	contentType := sniffed
+	if mt, _, err := mime.ParseMediaType(declared); err == nil && mt != "application/octet-stream" {
+		contentType = mt
+	}
+
+	return contentType, s.typeAllowed(contentType) && s.typeAllowed(sniffed)
+}
+
+// typeAllowed - тип есть в ATTACHMENTS_ALLOWED_TYPES, допустим шаблон вида image/*
+func (s *service) typeAllowed(contentType string) bool {
+	for _, allowed := range s.limits.AllowedTypes {
+		allowed = strings.TrimSpace(allowed)
+
+		if allowed == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *service) attachmentError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrNoAttachment) {
+		return dto.NotFound(ctx)
+	}
+
+	return dto.InternalServerError(ctx)
+}
+
+// attachmentParams - id задачи и вложения из пути
+func attachmentParams(ctx *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid id parameter")
+	}
+
+	attachmentID, err := uuid.Parse(ctx.Params("aid"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid aid parameter")
+	}
+
+	return taskID, attachmentID, nil
+}
+
+// fileName - имя файла без пути, не длиннее maxFileName символов
+func fileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+
+	if utf8.RuneCountInString(name) > maxFileName {
+		name = string([]rune(name)[:maxFileName])
+	}
+
+	return name
+}
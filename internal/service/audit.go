@@ -0,0 +1,85 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+func (s *service) GetAudit(ctx *fiber.Ctx) error {
+	page, limit := queryPage(ctx)
+
+	filter := repos.AuditFilter{
+		Actor: ctx.Query("actor"),
+		Page:  page,
+		Limit: limit,
+	}
+
+	if v := ctx.Query("task_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task_id parameter")
+		}
+		filter.TaskId = &id
+	}
+
+	var err error
+
+	if filter.From, err = queryTime(ctx, "from"); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid from parameter, RFC3339 expected")
+	}
+
+	if filter.To, err = queryTime(ctx, "to"); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid to parameter, RFC3339 expected")
+	}
+
+	var entries AuditResponse
+
+	entries.Entries, err = s.repos.ListAudit(ctx.Context(), filter)
+	if err != nil {
+		s.log.Error("Failed to list audit entries", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrRange) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   entries,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) VerifyAudit(ctx *fiber.Ctx) error {
+	entries, err := s.repos.AuditChain(ctx.Context())
+	if err != nil {
+		s.log.Error("Failed to read audit chain", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	result := AuditVerifyResponse{
+		Checked:  len(entries),
+		BrokenAt: repos.VerifyAuditChain(entries),
+	}
+	result.Valid = result.BrokenAt == 0
+
+	if !result.Valid {
+		s.log.Errorw("Audit chain is broken", "seq", result.BrokenAt)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   result,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
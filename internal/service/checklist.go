@@ -0,0 +1,201 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+func (s *service) GetChecklist(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	items, err := s.repos.ListChecklist(ctx.Context(), owner, taskID)
+	if err != nil {
+		s.log.Error("Failed to list checklist", zap.Error(err))
+		return s.checklistError(ctx, err)
+	}
+
+	return checklistResponse(ctx, fiber.StatusOK, items)
+}
+
+// AddChecklistItem - добавляет пункт в конец чек-листа
+func (s *service) AddChecklistItem(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req ChecklistItemRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	items, err := s.repos.AddChecklistItem(ctx.Context(), owner, taskID, repos.ChecklistItem{
+		Id:   uuid.New(),
+		Text: req.Text,
+		Done: req.Done,
+	})
+	if err != nil {
+		s.log.Error("Failed to add checklist item", zap.Error(err))
+		return s.checklistError(ctx, err)
+	}
+
+	return checklistResponse(ctx, fiber.StatusCreated, items)
+}
+
+// MoveChecklistItem - ставит пункт на позицию из тела, остальные сдвигаются
+func (s *service) MoveChecklistItem(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, itemID, err := checklistParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var req MoveChecklistItemRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	items, err := s.repos.MoveChecklistItem(ctx.Context(), owner, taskID, itemID, req.Position)
+	if err != nil {
+		s.log.Error("Failed to move checklist item", zap.Error(err))
+		return s.checklistError(ctx, err)
+	}
+
+	return checklistResponse(ctx, fiber.StatusOK, items)
+}
+
+// ToggleChecklistItem - отмечает пункт; без тела переключает его
+func (s *service) ToggleChecklistItem(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, itemID, err := checklistParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var req ToggleChecklistItemRequest
+
+	if len(ctx.Body()) > 0 {
+		if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+			s.log.Error("Invalid request body", zap.Error(err))
+			return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		}
+	}
+
+	items, err := s.repos.ToggleChecklistItem(ctx.Context(), owner, taskID, itemID, req.Done)
+	if err != nil {
+		s.log.Error("Failed to toggle checklist item", zap.Error(err))
+		return s.checklistError(ctx, err)
+	}
+
+	return checklistResponse(ctx, fiber.StatusOK, items)
+}
+
+func (s *service) RemoveChecklistItem(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, itemID, err := checklistParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	items, err := s.repos.RemoveChecklistItem(ctx.Context(), owner, taskID, itemID)
+	if err != nil {
+		s.log.Error("Failed to remove checklist item", zap.Error(err))
+		return s.checklistError(ctx, err)
+	}
+
+	return checklistResponse(ctx, fiber.StatusOK, items)
+}
+
+// checklistResponse - пункты чек-листа вместе с прогрессом
+func checklistResponse(ctx *fiber.Ctx, status int, items []repos.ChecklistItem) error {
+	progress := repos.ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data: ChecklistResponse{
+			Items:    items,
+			Progress: progress,
+		},
+	}
+
+	return ctx.Status(status).JSON(response)
+}
+
+// checklistParams - id задачи и пункта чек-листа из пути
+func checklistParams(ctx *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid id parameter")
+	}
+
+	itemID, err := uuid.Parse(ctx.Params("iid"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid iid parameter")
+	}
+
+	return taskID, itemID, nil
+}
+
+func (s *service) checklistError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrNoChecklistItem) {
+		return dto.NotFound(ctx)
+	}
+
+	return dto.InternalServerError(ctx)
+}
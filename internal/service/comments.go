@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+func (s *service) AddComment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req CommentRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var comment CommentResponse
+
+	comment.Comment, err = s.repos.AddComment(ctx.Context(), owner, repos.Comment{
+		Id:     uuid.New(),
+		TaskId: taskID,
+		Author: owner,
+		Body:   req.Body,
+	})
+	if err != nil {
+		s.log.Error("Failed to add comment", zap.Error(err))
+		return s.commentError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   comment,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (s *service) GetComments(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	page, limit := queryPage(ctx)
+
+	var comments AllCommentsResponse
+
+	comments.Comments, err = s.repos.ListComments(ctx.Context(), owner, taskID, page, limit)
+	if err != nil {
+		s.log.Error("Failed to list comments", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrRange) {
+			return dto.NotFound(ctx)
+		}
+
+		return s.commentError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   comments,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) UpdateComment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, commentID, err := commentParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var req CommentRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var comment CommentResponse
+
+	comment.Comment, err = s.repos.UpdateComment(ctx.Context(), owner, taskID, commentID, req.Body)
+	if err != nil {
+		s.log.Error("Failed to update comment", zap.Error(err))
+		return s.commentError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   comment,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) DeleteComment(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, commentID, err := commentParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	if err = s.repos.DeleteComment(ctx.Context(), owner, taskID, commentID); err != nil {
+		s.log.Error("Failed to delete comment", zap.Error(err))
+		return s.commentError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// commentParams - id задачи и комментария из пути
+func commentParams(ctx *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid id parameter")
+	}
+
+	commentID, err := uuid.Parse(ctx.Params("cid"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid cid parameter")
+	}
+
+	return taskID, commentID, nil
+}
+
+func (s *service) commentError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrCommentNotFound) {
+		return dto.NotFound(ctx)
+	}
+
+	if errors.Is(err, myerr.ErrCommentAuthor) {
+		return dto.Forbidden(ctx, dto.NotCommentAuthor, myerr.ErrCommentAuthor.Error())
+	}
+
+	return dto.InternalServerError(ctx)
+}
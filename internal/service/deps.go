@@ -0,0 +1,236 @@
+package service
+
+import (
+	"container/heap"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+func (s *service) AddDependency(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req DependencyRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	blocker, err := uuid.Parse(req.BlockerID)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid blocker_id")
+	}
+
+	err = s.repos.AddDependency(ctx.Context(), owner, repos.Dependency{TaskId: id, BlockerId: blocker})
+	if err != nil {
+		s.log.Error("Failed to add dependency", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		if errors.Is(err, myerr.ErrDependencyCycle) {
+			return dto.Conflict(ctx, dto.DependencyCycle, myerr.ErrDependencyCycle.Error())
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	return s.dependencies(ctx, owner, id, fiber.StatusCreated)
+}
+
+func (s *service) GetDependencies(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	return s.dependencies(ctx, owner, id, fiber.StatusOK)
+}
+
+func (s *service) RemoveDependency(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	blocker, err := uuid.Parse(ctx.Params("blocker_id"))
+	if err != nil {
+		s.log.Error("Invalid blocker_id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid blocker_id parameter")
+	}
+
+	err = s.repos.RemoveDependency(ctx.Context(), owner, repos.Dependency{TaskId: id, BlockerId: blocker})
+	if err != nil {
+		s.log.Error("Failed to remove dependency", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrNoDependency) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	return s.dependencies(ctx, owner, id, fiber.StatusOK)
+}
+
+// dependencies - ответ со списком задач, блокирующих id
+func (s *service) dependencies(ctx *fiber.Ctx, owner string, id uuid.UUID, status int) error {
+	var deps DependenciesResponse
+	var err error
+
+	deps.Blockers, err = s.repos.GetBlockers(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to get blockers", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   deps,
+	}
+
+	return ctx.Status(status).JSON(response)
+}
+
+func (s *service) GetTaskOrder(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	tasks, deps, err := s.repos.DependencyGraph(ctx.Context(), owner)
+	if err != nil {
+		s.log.Error("Failed to get dependency graph", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	var order TaskOrderResponse
+
+	order.Tasks, err = topoSort(tasks, deps)
+	if err != nil {
+		s.log.Error("Failed to order tasks", zap.Error(err))
+		return dto.Conflict(ctx, dto.DependencyCycle, myerr.ErrDependencyCycle.Error())
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   order,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// topoSort - алгоритм Кана; среди готовых задач первой идёт более ранняя,
+// чтобы порядок был стабильным между запросами
+func topoSort(tasks []repos.Task, deps []repos.Dependency) ([]repos.Task, error) {
+	index := make(map[uuid.UUID]int, len(tasks))
+	for i, t := range tasks {
+		index[t.Id] = i
+	}
+
+	indegree := make([]int, len(tasks))
+	blocks := make([][]int, len(tasks))
+
+	for _, d := range deps {
+		task, ok := index[d.TaskId]
+		if !ok {
+			continue
+		}
+
+		blocker, ok := index[d.BlockerId]
+		if !ok {
+			continue
+		}
+
+		blocks[blocker] = append(blocks[blocker], task)
+		indegree[task]++
+	}
+
+	ready := make(readyHeap, 0, len(tasks))
+	for i := range tasks {
+		if indegree[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	heap.Init(&ready)
+
+	ordered := make([]repos.Task, 0, len(tasks))
+
+	for ready.Len() > 0 {
+		cur := heap.Pop(&ready).(int)
+		ordered = append(ordered, tasks[cur])
+
+		for _, next := range blocks[cur] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				heap.Push(&ready, next)
+			}
+		}
+	}
+
+	if len(ordered) != len(tasks) {
+		return nil, myerr.ErrDependencyCycle
+	}
+
+	return ordered, nil
+}
+
+// readyHeap - готовые задачи topoSort, минимальный индекс (более ранняя задача) наверху
+type readyHeap []int
+
+func (h readyHeap) Len() int           { return len(h) }
+func (h readyHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h readyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *readyHeap) Push(x any) {
+	*h = append(*h, x.(int))
+}
+
+func (h *readyHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+
+	return x
+}
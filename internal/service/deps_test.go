@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+func TestTopoSort(t *testing.T) {
+	tasks := make([]repos.Task, 5)
+	for i := range tasks {
+		tasks[i].Id = uuid.New()
+	}
+
+	dep := func(task, blocker int) repos.Dependency {
+		return repos.Dependency{TaskId: tasks[task].Id, BlockerId: tasks[blocker].Id}
+	}
+
+	// 0 ждёт 3, 1 ждёт 4: среди готовых первой идёт более ранняя задача
+	ordered, err := topoSort(tasks, []repos.Dependency{dep(0, 3), dep(1, 4), dep(2, 3)})
+	if err != nil {
+		t.Fatalf("topoSort: %v", err)
+	}
+
+	want := []int{3, 0, 2, 4, 1}
+	for i, task := range ordered {
+		if task.Id != tasks[want[i]].Id {
+			t.Fatalf("position %d: got task %v, want %d", i, task.Id, want[i])
+		}
+	}
+
+	if _, err = topoSort(tasks, []repos.Dependency{dep(0, 1), dep(1, 2), dep(2, 0)}); !errors.Is(err, myerr.ErrDependencyCycle) {
+		t.Fatalf("cycle: got %v, want %v", err, myerr.ErrDependencyCycle)
+	}
+}
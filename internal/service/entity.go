@@ -1,20 +1,80 @@
 package service
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
 	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/internal/workflow"
 )
 
 // TaskRequest - структура, представляющая тело запроса
 type TaskRequest struct {
-	ID          string `json:"id"`
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description"`
+	ID          string             `json:"id"`
+	Title       string             `json:"title" validate:"required"`
+	Description string             `json:"description"`
+	DueAt       *time.Time         `json:"due_at"`
+	Tags        []string           `json:"tags" validate:"dive,tag"`
+	ParentID    string             `json:"parent_id"`
+	ProjectID   string             `json:"project_id"` // по умолчанию - проект DEFAULT
+	Recurrence  *RecurrenceRequest `json:"recurrence"` // требует due_at, от него считаются повторения
+	Fields      map[string]any     `json:"fields"`     // значения пользовательских полей проекта
+	EstimateRequest
 }
 
 type UpdateTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Title           string             `json:"title"`
+	Description     string             `json:"description"`
+	Status          string             `json:"status"`
+	DueAt           *time.Time         `json:"due_at"`
+	ClearDueAt      bool               `json:"clear_due_at"`             // снять срок
+	Tags            []string           `json:"tags" validate:"dive,tag"` // заменяет теги задачи, [] - снять все
+	ParentID        string             `json:"parent_id"`                // перенести в подзадачи другой задачи
+	ClearParent     bool               `json:"clear_parent"`             // сделать задачей верхнего уровня
+	ProjectID       string             `json:"project_id"`               // перенести в другой проект
+	Recurrence      *RecurrenceRequest `json:"recurrence"`               // начать новую серию повторений
+	ClearRecurrence bool               `json:"clear_recurrence"`         // больше не повторять
+	EstimateRequest
+	ClearPoints  bool           `json:"clear_estimate_points"`  // снять оценку в пунктах
+	ClearMinutes bool           `json:"clear_estimate_minutes"` // снять оценку во времени
+	Fields       map[string]any `json:"fields"`                 // заданные ключи меняются, null - снять значение
+}
+
+// EstimateRequest - оценка задачи в пунктах и минутах (не больше года)
+type EstimateRequest struct {
+	Points  *int `json:"estimate_points" validate:"omitempty,gte=0,lte=1000"`
+	Minutes *int `json:"estimate_minutes" validate:"omitempty,gte=0,lte=525600"`
+}
+
+// RecurrenceRequest - правило повторения: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, COUNT, UNTIL
+type RecurrenceRequest struct {
+	Rule     string `json:"rule" validate:"required,max=500"`
+	Timezone string `json:"timezone" validate:"max=64"` // по умолчанию UTC
+}
+
+// EstimateTotals - сумма оценок выполненных и оставшихся задач
+type EstimateTotals struct {
+	Completed int `json:"completed"`
+	Remaining int `json:"remaining"`
+}
+
+type EstimateSummary struct {
+	Points      EstimateTotals `json:"points"`
+	Minutes     EstimateTotals `json:"minutes"`
+	Unestimated int            `json:"unestimated"` // задачи без оценки
+}
+
+// TaskEstimate - сводка по задаче вместе со всеми подзадачами
+type TaskEstimate struct {
+	TaskId uuid.UUID `json:"task_id"`
+	EstimateSummary
+}
+
+type EstimateSummaryResponse struct {
+	Tasks []TaskEstimate  `json:"tasks"`
+	Total EstimateSummary `json:"total"` // каждая задача учитывается один раз
 }
 
 type TaskResponse struct {
@@ -24,3 +84,218 @@ type TaskResponse struct {
 type AllTasksResponse struct {
 	Tasks []repos.Task `json:"all_tasks"`
 }
+
+// TaskNode - задача с вложенными подзадачами (GET /task/:id?tree=true)
+type TaskNode struct {
+	repos.Task
+	Children []*TaskNode `json:"children"`
+}
+
+type ChildrenResponse struct {
+	Children []repos.Task `json:"children"`
+}
+
+type TrashResponse struct {
+	Tasks []repos.Task `json:"tasks"`
+}
+
+type HistoryResponse struct {
+	Revisions []repos.Revision `json:"revisions"`
+}
+
+// FieldChange - изменение поля задачи между ревизиями, значения в JSON
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"` // null - поля не было
+	New   json.RawMessage `json:"new"` // null - поле убрано
+}
+
+type RevisionResponse struct {
+	Revision repos.Revision `json:"revision"`
+	Changes  []FieldChange  `json:"changes"`
+}
+
+type WorkflowResponse struct {
+	Workflow workflow.Definition `json:"workflow"`
+}
+
+type TagsResponse struct {
+	Tags []repos.TagCount `json:"tags"`
+}
+
+// ProjectRequest - тело запроса на создание проекта
+type ProjectRequest struct {
+	Key         string `json:"key" validate:"required,projectkey"`
+	Name        string `json:"name" validate:"required,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	Archived    bool   `json:"archived"`
+}
+
+// UpdateProjectRequest - пустые поля не меняются
+type UpdateProjectRequest struct {
+	Key         string `json:"key" validate:"omitempty,projectkey"`
+	Name        string `json:"name" validate:"max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	Archived    *bool  `json:"archived"`
+}
+
+type ProjectResponse struct {
+	Project repos.Project `json:"project"`
+}
+
+type AllProjectsResponse struct {
+	Projects []repos.Project `json:"projects"`
+}
+
+// FieldRequest - тело запроса на создание пользовательского поля проекта
+type FieldRequest struct {
+	Key  string `json:"key" validate:"required,fieldkey"`
+	Type string `json:"type" validate:"required,oneof=string number enum date bool"`
+	FieldSchemaRequest
+}
+
+// FieldSchemaRequest - настройки поля; при изменении заменяются целиком, ключ и тип не меняются
+type FieldSchemaRequest struct {
+	Name     string   `json:"name" validate:"required,max=200"`
+	Required bool     `json:"required"`
+	Min      *float64 `json:"min"` // string - длина, number - значение
+	Max      *float64 `json:"max"`
+	MinDate  string   `json:"min_date"` // для date, YYYY-MM-DD
+	MaxDate  string   `json:"max_date"`
+	Options  []string `json:"options" validate:"max=100,dive,required,max=200"` // для enum
+}
+
+type FieldResponse struct {
+	Field repos.CustomField `json:"field"`
+}
+
+type AllFieldsResponse struct {
+	Fields []repos.CustomField `json:"fields"`
+}
+
+// AssigneeRequest - тело запроса POST /task/:id/assignees, "me" - сам вызывающий
+type AssigneeRequest struct {
+	Assignee string `json:"assignee" validate:"required,max=200"`
+}
+
+// DependencyRequest - тело запроса POST /task/:id/dependencies
+type DependencyRequest struct {
+	BlockerID string `json:"blocker_id" validate:"required"`
+}
+
+type DependenciesResponse struct {
+	Blockers []repos.Task `json:"blockers"`
+}
+
+// CommentRequest - тело запроса на создание и изменение комментария
+type CommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type CommentResponse struct {
+	Comment repos.Comment `json:"comment"`
+}
+
+type AllCommentsResponse struct {
+	Comments []repos.Comment `json:"comments"`
+}
+
+// TimerRequest - необязательное тело запуска и остановки таймера
+type TimerRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+// WorklogRequest - запись времени вручную
+type WorklogRequest struct {
+	Start *time.Time `json:"start" validate:"required"`
+	End   *time.Time `json:"end" validate:"required"`
+	Note  string     `json:"note" validate:"max=1000"`
+}
+
+// UpdateWorklogRequest - изменение записи времени, незаданные поля не меняются
+type UpdateWorklogRequest struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	Note  *string    `json:"note" validate:"omitempty,max=1000"`
+}
+
+type WorklogResponse struct {
+	Worklog repos.Worklog `json:"worklog"`
+}
+
+// AllWorklogsResponse - страница записей и итоги по всем записям задачи
+type AllWorklogsResponse struct {
+	Worklogs []repos.Worklog  `json:"worklogs"`
+	Totals   []repos.UserTime `json:"totals"`
+	Total    int64            `json:"total"` // секунды
+}
+
+type WorklogReportResponse struct {
+	From  time.Time        `json:"from"`
+	To    time.Time        `json:"to"`
+	Tasks []repos.TaskTime `json:"tasks"`
+	Total int64            `json:"total"` // секунды
+}
+
+// ChecklistItemRequest - новый пункт чек-листа
+type ChecklistItemRequest struct {
+	Text string `json:"text" validate:"required,max=500"`
+	Done bool   `json:"done"`
+}
+
+// MoveChecklistItemRequest - новая позиция пункта, с 1; за концом чек-листа - в конец
+type MoveChecklistItemRequest struct {
+	Position int `json:"position" validate:"gte=1"`
+}
+
+// ToggleChecklistItemRequest - необязательное тело отметки пункта, nil - переключить
+type ToggleChecklistItemRequest struct {
+	Done *bool `json:"done"`
+}
+
+type ChecklistResponse struct {
+	Items    []repos.ChecklistItem   `json:"items"`
+	Progress repos.ChecklistProgress `json:"progress"`
+}
+
+type AttachmentResponse struct {
+	Attachment repos.Attachment `json:"attachment"`
+}
+
+type AllAttachmentsResponse struct {
+	Attachments []repos.Attachment `json:"attachments"`
+}
+
+// TaskOrderResponse - задачи в порядке выполнения: блокирующие раньше зависимых
+type TaskOrderResponse struct {
+	Tasks []repos.Task `json:"tasks"`
+}
+
+// APIKeyRequest - тело запроса на выпуск API-ключа
+type APIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Subject   string     `json:"subject" validate:"max=200"` // только тот, кто выпускает ключ; поле можно не указывать
+	Scopes    []string   `json:"scopes"`                     // по умолчанию tasks:read и tasks:write
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyCreatedResponse - ключ в открытом виде возвращается только здесь
+type APIKeyCreatedResponse struct {
+	Key    string       `json:"key"`
+	APIKey repos.APIKey `json:"api_key"`
+}
+
+type AllAPIKeysResponse struct {
+	APIKeys []repos.APIKey `json:"api_keys"`
+}
+
+type AuditResponse struct {
+	Entries []repos.AuditEntry `json:"entries"`
+}
+
+// AuditVerifyResponse - результат проверки цепочки хешей журнала аудита
+type AuditVerifyResponse struct {
+	Valid    bool  `json:"valid"`
+	Checked  int   `json:"checked"`
+	BrokenAt int64 `json:"broken_at,omitempty"` // seq первой записи, на которой цепочка нарушена
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// maxEstimateTasks - сколько задач можно запросить в одной сводке
+const maxEstimateTasks = 100
+
+// GetEstimates - сводка оценок по задачам ?ids=a,b,c: выполнено и осталось, с учётом всех подзадач.
+// В общем итоге каждая задача считается один раз, даже если запрошены и она, и её предок.
+func (s *service) GetEstimates(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	ids, err := parseIDs(ctx.Query("ids"))
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var summary EstimateSummaryResponse
+	summary.Tasks = make([]TaskEstimate, 0, len(ids))
+	counted := make(map[uuid.UUID]bool)
+
+	for _, id := range ids {
+		tree, err := s.taskTree(ctx, owner, id)
+		if err != nil {
+			s.log.Error("Failed to get task tree", zap.Error(err))
+
+			if errors.Is(err, myerr.ErrTaskNotFound) {
+				return dto.NotFound(ctx)
+			}
+
+			return dto.InternalServerError(ctx)
+		}
+
+		estimate := TaskEstimate{TaskId: id}
+
+		for _, t := range tree {
+			s.addEstimate(&estimate.EstimateSummary, t)
+
+			if !counted[t.Id] {
+				counted[t.Id] = true
+				s.addEstimate(&summary.Total, t)
+			}
+		}
+
+		summary.Tasks = append(summary.Tasks, estimate)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   summary,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// taskTree - задача и все её подзадачи
+func (s *service) taskTree(ctx *fiber.Ctx, owner string, id uuid.UUID) ([]repos.Task, error) {
+	task, err := s.repos.GetTask(ctx.Context(), owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	descendants, err := s.repos.GetDescendants(ctx.Context(), owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]repos.Task{task}, descendants...), nil
+}
+
+// addEstimate - оценка задачи в выполненное или оставшееся по её статусу
+func (s *service) addEstimate(summary *EstimateSummary, t repos.Task) {
+	points, minutes := &summary.Points.Remaining, &summary.Minutes.Remaining
+	if s.workflow.IsDone(t.Status) {
+		points, minutes = &summary.Points.Completed, &summary.Minutes.Completed
+	}
+
+	if t.Points != nil {
+		*points += *t.Points
+	}
+
+	if t.Minutes != nil {
+		*minutes += *t.Minutes
+	}
+
+	if t.Points == nil && t.Minutes == nil {
+		summary.Unestimated++
+	}
+}
+
+// parseIDs - непустой список id через запятую без повторов, не больше maxEstimateTasks
+func parseIDs(value string) ([]uuid.UUID, error) {
+	if value == "" {
+		return nil, errors.New("ids parameter is required")
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > maxEstimateTasks {
+		return nil, errors.Errorf("at most %d ids are allowed", maxEstimateTasks)
+	}
+
+	ids := make([]uuid.UUID, 0, len(parts))
+	seen := make(map[uuid.UUID]bool)
+
+	for _, part := range parts {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.New("Invalid ids parameter")
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
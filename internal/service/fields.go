@@ -0,0 +1,279 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+// CreateField - добавляет пользовательское поле в схему проекта
+func (s *service) CreateField(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	projectID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req FieldRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	field, err := fieldSchema(req.Type, req.FieldSchemaRequest)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	field.Id = uuid.New()
+	field.ProjectId = projectID
+	field.Key = req.Key
+
+	var created FieldResponse
+
+	created.Field, err = s.repos.CreateField(ctx.Context(), owner, field)
+	if err != nil {
+		s.log.Error("Failed to create field", zap.Error(err))
+		return s.fieldError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   created,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (s *service) ListFields(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	projectID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var fields AllFieldsResponse
+
+	fields.Fields, err = s.repos.ListFields(ctx.Context(), owner, projectID)
+	if err != nil {
+		s.log.Error("Failed to list fields", zap.Error(err))
+		return s.fieldError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   fields,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// UpdateField - заменяет настройки поля; значения задач заново проверяются при следующем их изменении
+func (s *service) UpdateField(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	projectID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	key := ctx.Params("key")
+
+	var req FieldSchemaRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	// настройки проверяются по типу поля, тип не меняется
+	fields, err := s.repos.ListFields(ctx.Context(), owner, projectID)
+	if err != nil {
+		s.log.Error("Failed to list fields", zap.Error(err))
+		return s.fieldError(ctx, err)
+	}
+
+	var current *repos.CustomField
+	for i := range fields {
+		if fields[i].Key == key {
+			current = &fields[i]
+		}
+	}
+
+	if current == nil {
+		return dto.NotFound(ctx)
+	}
+
+	field, err := fieldSchema(current.Type, req)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	var updated FieldResponse
+
+	updated.Field, err = s.repos.UpdateField(ctx.Context(), owner, projectID, key, field)
+	if err != nil {
+		s.log.Error("Failed to update field", zap.Error(err))
+		return s.fieldError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   updated,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// DeleteField - удаляет поле из схемы проекта вместе со значениями в задачах
+func (s *service) DeleteField(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	projectID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	if err = s.repos.DeleteField(ctx.Context(), owner, projectID, ctx.Params("key")); err != nil {
+		s.log.Error("Failed to delete field", zap.Error(err))
+		return s.fieldError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// fieldSchema - настройки поля типа fieldType; границы и варианты допустимы только для своих типов
+func fieldSchema(fieldType string, req FieldSchemaRequest) (repos.CustomField, error) {
+	field := repos.CustomField{
+		Name:     req.Name,
+		Type:     fieldType,
+		Required: req.Required,
+		Min:      req.Min,
+		Max:      req.Max,
+		Options:  req.Options,
+	}
+
+	if (req.Min != nil || req.Max != nil) && fieldType != repos.FieldString && fieldType != repos.FieldNumber {
+		return field, errors.New("min and max are allowed only for string and number fields")
+	}
+
+	if req.Min != nil && req.Max != nil && *req.Min > *req.Max {
+		return field, errors.New("min can not be greater than max")
+	}
+
+	// для строки границы - длина, validator принимает только целые
+	if fieldType == repos.FieldString {
+		for _, bound := range []*float64{req.Min, req.Max} {
+			if bound != nil && (*bound < 0 || *bound != math.Trunc(*bound)) {
+				return field, errors.New("string length bounds must be non-negative integers")
+			}
+		}
+	}
+
+	if (req.MinDate != "" || req.MaxDate != "") && fieldType != repos.FieldDate {
+		return field, errors.New("min_date and max_date are allowed only for date fields")
+	}
+
+	var err error
+
+	if field.MinDate, err = fieldDate(req.MinDate); err != nil {
+		return field, errors.New("invalid min_date, YYYY-MM-DD expected")
+	}
+
+	if field.MaxDate, err = fieldDate(req.MaxDate); err != nil {
+		return field, errors.New("invalid max_date, YYYY-MM-DD expected")
+	}
+
+	if field.MinDate != nil && field.MaxDate != nil && field.MinDate.After(*field.MaxDate) {
+		return field, errors.New("min_date can not be after max_date")
+	}
+
+	if fieldType != repos.FieldEnum {
+		if len(req.Options) > 0 {
+			return field, errors.New("options are allowed only for enum fields")
+		}
+
+		return field, nil
+	}
+
+	if len(req.Options) == 0 {
+		return field, errors.New("enum field requires options")
+	}
+
+	seen := make(map[string]struct{}, len(req.Options))
+	for _, option := range req.Options {
+		if _, ok := seen[option]; ok {
+			return field, errors.New("duplicate option: " + option)
+		}
+		seen[option] = struct{}{}
+	}
+
+	return field, nil
+}
+
+// fieldDate - необязательная граница поля типа date
+func fieldDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(repos.FieldDateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &date, nil
+}
+
+func (s *service) fieldError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrProjectNotFound) || errors.Is(err, myerr.ErrFieldNotFound) {
+		return dto.NotFound(ctx)
+	}
+
+	if errors.Is(err, myerr.ErrFieldKeyTaken) {
+		return dto.Conflict(ctx, dto.FieldKeyTaken, myerr.ErrFieldKeyTaken.Error())
+	}
+
+	return dto.InternalServerError(ctx)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// diffIgnored - поля, которые меняются при любом изменении и в diff только мешают
+var diffIgnored = map[string]bool{"updated": true}
+
+func (s *service) GetHistory(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	page, limit := queryPage(ctx)
+
+	var history HistoryResponse
+
+	history.Revisions, err = s.repos.ListRevisions(ctx.Context(), owner, taskID, page, limit)
+	if err != nil {
+		s.log.Error("Failed to list revisions", zap.Error(err))
+		return s.revisionError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   history,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetRevision - ревизия и изменения полей относительно предыдущей
+func (s *service) GetRevision(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, rev, err := revisionParams(ctx)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var revision RevisionResponse
+
+	revision.Revision, err = s.repos.GetRevision(ctx.Context(), owner, taskID, rev)
+	if err != nil {
+		s.log.Error("Failed to get revision", zap.Error(err))
+		return s.revisionError(ctx, err)
+	}
+
+	var prev *repos.Task
+	if rev > 1 {
+		previous, err := s.repos.GetRevision(ctx.Context(), owner, taskID, rev-1)
+		if err != nil {
+			s.log.Error("Failed to get previous revision", zap.Error(err))
+			return s.revisionError(ctx, err)
+		}
+
+		prev = &previous.Task
+	}
+
+	revision.Changes, err = diffTasks(prev, revision.Revision.Task)
+	if err != nil {
+		s.log.Error("Failed to diff revisions", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   revision,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// RevertTask - возвращает поля задачи к ревизии; откат сам становится новой ревизией.
+// Статус и исполнители не откатываются: статус меняется только по workflow, исполнители - своими запросами.
+func (s *service) RevertTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, rev, err := revisionParams(ctx)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	revision, err := s.repos.GetRevision(ctx.Context(), owner, taskID, rev)
+	if err != nil {
+		s.log.Error("Failed to get revision", zap.Error(err))
+		return s.revisionError(ctx, err)
+	}
+
+	before, err := s.repos.GetTask(ctx.Context(), owner, taskID)
+	if err != nil {
+		s.log.Error("Failed to get task", zap.Error(err))
+		return s.revisionError(ctx, err)
+	}
+
+	var task TaskResponse
+
+	task.Task, err = s.repos.UpdateTask(ctx.Context(), owner, revertTask(before, revision.Task), taskID)
+	if err != nil {
+		s.log.Error("Failed to revert task", zap.Error(err))
+		return s.updateTaskError(ctx, err, before.Status, before.Status)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// revertTask - изменение, которое возвращает current к снимку snapshot
+func revertTask(current, snapshot repos.Task) repos.UpdateTask {
+	update := repos.UpdateTask{
+		Title:            snapshot.Title,
+		Description:      snapshot.Description,
+		ClearDescription: snapshot.Description == "",
+		DueAt:            snapshot.DueAt,
+		ClearDueAt:       snapshot.DueAt == nil,
+		Tags:             repos.NormalizeTags(snapshot.Tags),
+		ParentId:         snapshot.ParentId,
+		ClearParent:      snapshot.ParentId == nil,
+		ProjectId:        &snapshot.ProjectId,
+		Estimate:         snapshot.Estimate,
+		ClearPoints:      snapshot.Points == nil,
+		ClearMinutes:     snapshot.Minutes == nil,
+	}
+
+	// серия повторений начинается заново, только если правило действительно другое
+	switch old, cur := snapshot.Recurrence, current.Recurrence; {
+	case old == nil:
+		update.ClearRecurrence = cur != nil
+	case cur == nil || cur.Rule != old.Rule || cur.TZ != old.TZ || !cur.Start.Equal(old.Start):
+		update.Recurrence = &repos.Recurrence{Rule: old.Rule, TZ: old.TZ, Start: old.Start, Seq: old.Seq}
+	}
+
+	// значения полей, которых не было в снимке, снимаются
+	update.Fields = make(map[string]any, len(current.Fields)+len(snapshot.Fields))
+	for key := range current.Fields {
+		update.Fields[key] = nil
+	}
+	for key, value := range snapshot.Fields {
+		update.Fields[key] = value
+	}
+
+	return update
+}
+
+// diffTasks - изменённые поля задачи по JSON-представлению; prev = nil - первая ревизия
+func diffTasks(prev *repos.Task, next repos.Task) ([]FieldChange, error) {
+	before := make(map[string]json.RawMessage)
+	if prev != nil {
+		raw, err := json.Marshal(prev)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(raw, &before); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := json.Marshal(next)
+	if err != nil {
+		return nil, err
+	}
+
+	after := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(raw, &after); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		fields = append(fields, field)
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]FieldChange, 0)
+	for _, field := range fields {
+		if diffIgnored[field] || string(before[field]) == string(after[field]) {
+			continue
+		}
+
+		changes = append(changes, FieldChange{Field: field, Old: before[field], New: after[field]})
+	}
+
+	return changes, nil
+}
+
+func revisionParams(ctx *fiber.Ctx) (uuid.UUID, int, error) {
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return uuid.Nil, 0, errors.New("Invalid id parameter")
+	}
+
+	rev, err := strconv.Atoi(ctx.Params("rev"))
+	if err != nil || rev < 1 {
+		return uuid.Nil, 0, errors.New("Invalid rev parameter")
+	}
+
+	return taskID, rev, nil
+}
+
+func (s *service) revisionError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrTaskNotFound) || errors.Is(err, myerr.ErrNoRevision) || errors.Is(err, myerr.ErrRange) {
+		return dto.NotFound(ctx)
+	}
+
+	return dto.InternalServerError(ctx)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+func (s *service) CreateProject(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	var req ProjectRequest
+
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var project ProjectResponse
+	var err error
+
+	project.Project, err = s.repos.CreateProject(ctx.Context(), owner, repos.Project{
+		Id:          uuid.New(),
+		Key:         req.Key,
+		Name:        req.Name,
+		Description: req.Description,
+		Archived:    req.Archived,
+	})
+	if err != nil {
+		s.log.Error("Failed to create project", zap.Error(err))
+		return s.projectError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   project,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (s *service) GetProject(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var project ProjectResponse
+
+	project.Project, err = s.repos.GetProject(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to get project", zap.Error(err))
+		return s.projectError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   project,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) ListProjects(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	page, limit := queryPage(ctx)
+
+	filter := repos.ProjectFilter{
+		Page:  page,
+		Limit: limit,
+	}
+
+	if v := ctx.Query("archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid archived parameter")
+		}
+		filter.Archived = &archived
+	}
+
+	var projects AllProjectsResponse
+	var err error
+
+	projects.Projects, err = s.repos.ListProjects(ctx.Context(), owner, filter)
+	if err != nil {
+		s.log.Error("Failed to list projects", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrRange) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   projects,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) UpdateProject(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req UpdateProjectRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var project ProjectResponse
+
+	project.Project, err = s.repos.UpdateProject(ctx.Context(), owner, id, repos.UpdateProject{
+		Key:         req.Key,
+		Name:        req.Name,
+		Description: req.Description,
+		Archived:    req.Archived,
+	})
+	if err != nil {
+		s.log.Error("Failed to update project", zap.Error(err))
+		return s.projectError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   project,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) DeleteProject(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	if err = s.repos.DeleteProject(ctx.Context(), owner, id); err != nil {
+		s.log.Error("Failed to delete project", zap.Error(err))
+		return s.projectError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) GetProjectTasks(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	if _, err = s.repos.GetProject(ctx.Context(), owner, id); err != nil {
+		s.log.Error("Failed to get project", zap.Error(err))
+		return s.projectError(ctx, err)
+	}
+
+	return s.listTasks(ctx, owner, &id, false)
+}
+
+func (s *service) projectError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, myerr.ErrProjectNotFound) {
+		return dto.NotFound(ctx)
+	}
+
+	if errors.Is(err, myerr.ErrProjectKeyTaken) {
+		return dto.Conflict(ctx, dto.ProjectKeyTaken, myerr.ErrProjectKeyTaken.Error())
+	}
+
+	if errors.Is(err, myerr.ErrProjectNotEmpty) {
+		return dto.Conflict(ctx, dto.ProjectNotEmpty, myerr.ErrProjectNotEmpty.Error())
+	}
+
+	return dto.InternalServerError(ctx)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// queryTime - необязательный параметр запроса в формате RFC3339
+func queryTime(ctx *fiber.Ctx, key string) (*time.Time, error) {
+	v := ctx.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// queryTag - фильтр по тегу, "#" можно не указывать: ?tag=bug и ?tag=%23bug равнозначны
+func queryTag(ctx *fiber.Ctx) string {
+	tag := ctx.Query("tag")
+	if tag == "" || strings.HasPrefix(tag, "#") {
+		return tag
+	}
+
+	return "#" + tag
+}
+
+// queryFields - фильтры по пользовательским полям: ?field.severity=high&field.customer=acme
+func queryFields(ctx *fiber.Ctx) map[string]string {
+	var fields map[string]string
+
+	for key, value := range ctx.Queries() {
+		if name, ok := strings.CutPrefix(key, "field."); ok && name != "" {
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			fields[name] = value
+		}
+	}
+
+	return fields
+}
+
+// queryPage - параметры page и limit: page от 1, limit от 1 до 100, иначе значения по умолчанию
+func queryPage(ctx *fiber.Ctx) (int, int) {
+	page := ctx.QueryInt("page", 1)
+
+	if page < 1 {
+		page = 1
+	}
+
+	limit := ctx.QueryInt("limit", 10)
+
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit
+}
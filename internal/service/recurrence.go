@@ -0,0 +1,46 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/recur"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+const defaultTimezone = "UTC"
+
+// parseRecurrence - проверяет правило и часовой пояс; серия начинается со срока задачи
+func parseRecurrence(req *RecurrenceRequest, due *time.Time) (*repos.Recurrence, error) {
+	if req == nil {
+		return nil, nil
+	}
+
+	if due == nil {
+		return nil, errors.New("recurrence requires due_at")
+	}
+
+	rule, err := recur.Parse(req.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	tz := req.Timezone
+	if tz == "" {
+		tz = defaultTimezone
+	}
+
+	// Local зависит от сервера, поэтому принимаем только зоны IANA
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, errors.Errorf("unknown timezone %q", tz)
+	}
+
+	return &repos.Recurrence{
+		Rule:  rule.String(),
+		TZ:    loc.String(),
+		Start: due.UTC(),
+		Seq:   1,
+	}, nil
+}
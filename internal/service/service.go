@@ -2,15 +2,20 @@ package service
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/volkowlad/week4/internal/auth"
+	"github.com/volkowlad/week4/internal/blob"
+	"github.com/volkowlad/week4/internal/config"
 	"github.com/volkowlad/week4/internal/dto"
 	"github.com/volkowlad/week4/internal/myerr"
 	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/internal/workflow"
 	"github.com/volkowlad/week4/pkg/validator"
 )
 
@@ -23,22 +28,110 @@ type Service interface {
 	GetAllTasks(ctx *fiber.Ctx) error
 	DeleteTask(ctx *fiber.Ctx) error
 	UpdateTask(ctx *fiber.Ctx) error
+	GetTags(ctx *fiber.Ctx) error
+	GetChildren(ctx *fiber.Ctx) error
+	AssignTask(ctx *fiber.Ctx) error
+	UnassignTask(ctx *fiber.Ctx) error
+
+	AddDependency(ctx *fiber.Ctx) error
+	GetDependencies(ctx *fiber.Ctx) error
+	RemoveDependency(ctx *fiber.Ctx) error
+	GetTaskOrder(ctx *fiber.Ctx) error
+	GetEstimates(ctx *fiber.Ctx) error
+
+	AddComment(ctx *fiber.Ctx) error
+	GetComments(ctx *fiber.Ctx) error
+	UpdateComment(ctx *fiber.Ctx) error
+	DeleteComment(ctx *fiber.Ctx) error
+
+	UploadAttachment(ctx *fiber.Ctx) error
+	ListAttachments(ctx *fiber.Ctx) error
+	DownloadAttachment(ctx *fiber.Ctx) error
+	DeleteAttachment(ctx *fiber.Ctx) error
+
+	GetAudit(ctx *fiber.Ctx) error
+	VerifyAudit(ctx *fiber.Ctx) error
+
+	CreateAPIKey(ctx *fiber.Ctx) error
+	ListAPIKeys(ctx *fiber.Ctx) error
+	RevokeAPIKey(ctx *fiber.Ctx) error
+
+	CreateProject(ctx *fiber.Ctx) error
+	GetProject(ctx *fiber.Ctx) error
+	ListProjects(ctx *fiber.Ctx) error
+	UpdateProject(ctx *fiber.Ctx) error
+	DeleteProject(ctx *fiber.Ctx) error
+	GetProjectTasks(ctx *fiber.Ctx) error
+
+	CreateField(ctx *fiber.Ctx) error
+	ListFields(ctx *fiber.Ctx) error
+	UpdateField(ctx *fiber.Ctx) error
+	DeleteField(ctx *fiber.Ctx) error
+
+	GetWorkflow(ctx *fiber.Ctx) error
+
+	GetHistory(ctx *fiber.Ctx) error
+	GetRevision(ctx *fiber.Ctx) error
+	RevertTask(ctx *fiber.Ctx) error
+
+	GetTrash(ctx *fiber.Ctx) error
+	RestoreTask(ctx *fiber.Ctx) error
+	PurgeTask(ctx *fiber.Ctx) error
+
+	GetArchive(ctx *fiber.Ctx) error
+	UnarchiveTask(ctx *fiber.Ctx) error
+
+	StartTimer(ctx *fiber.Ctx) error
+	StopTimer(ctx *fiber.Ctx) error
+	AddWorklog(ctx *fiber.Ctx) error
+	GetWorklogs(ctx *fiber.Ctx) error
+	UpdateWorklog(ctx *fiber.Ctx) error
+	DeleteWorklog(ctx *fiber.Ctx) error
+	GetWorklogReport(ctx *fiber.Ctx) error
+
+	GetChecklist(ctx *fiber.Ctx) error
+	AddChecklistItem(ctx *fiber.Ctx) error
+	MoveChecklistItem(ctx *fiber.Ctx) error
+	ToggleChecklistItem(ctx *fiber.Ctx) error
+	RemoveChecklistItem(ctx *fiber.Ctx) error
 }
 
 type service struct {
-	repos repos.Repository
-	log   *zap.SugaredLogger
+	repos    repos.Repository
+	files    blob.Store
+	limits   config.Attachments
+	workflow *workflow.Workflow
+	log      *zap.SugaredLogger
 }
 
 // NewService - конструктор сервиса
-func NewService(repos repos.Repository, logger *zap.SugaredLogger) Service {
+func NewService(repos repos.Repository, files blob.Store, limits config.Attachments, wf *workflow.Workflow,
+	logger *zap.SugaredLogger) Service {
 	return &service{
-		repos: repos,
-		log:   logger,
+		repos:    repos,
+		files:    files,
+		limits:   limits,
+		workflow: wf,
+		log:      logger,
 	}
 }
 
+// caller - идентификатор вызывающего, выставленный mw.Authorization
+func caller(ctx *fiber.Ctx) (string, bool) {
+	id, ok := auth.FromCtx(ctx)
+	if !ok || id.Subject == "" {
+		return "", false
+	}
+
+	return id.Subject, true
+}
+
 func (s *service) CreateTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
 	var req TaskRequest
 
 	// Десериализация JSON-запроса
@@ -57,21 +150,64 @@ func (s *service) CreateTask(ctx *fiber.Ctx) error {
 		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id")
 	}
 
+	parentID, err := parseOptionalID(req.ParentID)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid parent_id")
+	}
+
+	projectID, err := parseOptionalID(req.ProjectID)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid project_id")
+	}
+
+	recurrence, err := parseRecurrence(req.Recurrence, req.DueAt)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
 	// Вставка задачи в БД через репозиторий
 	task := repos.TaskCreate{
 		Id:          id,
 		Title:       req.Title,
 		Description: req.Description,
+		DueAt:       utc(req.DueAt),
+		Tags:        repos.NormalizeTags(req.Tags),
+		ParentId:    parentID,
+		ProjectId:   projectID,
+		Recurrence:  recurrence,
+		Fields:      req.Fields,
+		Estimate:    repos.Estimate{Points: req.Points, Minutes: req.Minutes},
 	}
 
-	err = s.repos.CreateTask(ctx.Context(), task)
+	err = s.repos.CreateTask(ctx.Context(), owner, task)
 	if err != nil {
 		s.log.Error("Failed to insert task", zap.Error(err))
 
+		var fieldErr *myerr.FieldValueError
+		if errors.As(err, &fieldErr) {
+			return dto.BadResponseError(ctx, dto.FieldIncorrect, fieldErr.Error())
+		}
+
 		if errors.Is(err, myerr.ErrTitle) {
 			return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
 		}
 
+		if errors.Is(err, myerr.ErrTaskExists) {
+			return dto.Conflict(ctx, dto.TaskExists, myerr.ErrTaskExists.Error())
+		}
+
+		if errors.Is(err, myerr.ErrParentNotFound) {
+			return dto.BadResponseError(ctx, dto.FieldIncorrect, "Parent task not found")
+		}
+
+		if errors.Is(err, myerr.ErrProjectNotFound) {
+			return dto.BadResponseError(ctx, dto.FieldIncorrect, "Project not found")
+		}
+
+		if errors.Is(err, myerr.ErrProjectArchived) {
+			return dto.Conflict(ctx, dto.ProjectArchived, myerr.ErrProjectArchived.Error())
+		}
+
 		return dto.InternalServerError(ctx)
 	}
 
@@ -85,6 +221,11 @@ func (s *service) CreateTask(ctx *fiber.Ctx) error {
 }
 
 func (s *service) GetTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
 		s.log.Error("Invalid id parameter", zap.Error(err))
@@ -93,7 +234,7 @@ func (s *service) GetTask(ctx *fiber.Ctx) error {
 	}
 
 	var task repos.Task
-	task, err = s.repos.GetTask(ctx.Context(), id)
+	task, err = s.repos.GetTask(ctx.Context(), owner, id)
 	if err != nil {
 		s.log.Error("Failed to get task", zap.Error(err))
 
@@ -108,6 +249,10 @@ func (s *service) GetTask(ctx *fiber.Ctx) error {
 		return dto.InternalServerError(ctx)
 	}
 
+	if ctx.QueryBool("tree", false) {
+		return s.getTaskTree(ctx, owner, task)
+	}
+
 	response := dto.Response{
 		Status: "success",
 		Data:   task,
@@ -117,22 +262,48 @@ func (s *service) GetTask(ctx *fiber.Ctx) error {
 }
 
 func (s *service) GetAllTasks(ctx *fiber.Ctx) error {
-	page := ctx.QueryInt("page", 1)
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
 
-	if page < 1 {
-		page = 1
+	projectID, err := parseOptionalID(ctx.Query("project_id"))
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid project_id parameter")
 	}
 
-	limit := ctx.QueryInt("limit", 10)
+	return s.listTasks(ctx, owner, projectID, false)
+}
 
-	if limit < 1 || limit > 100 {
-		limit = 10
+// listTasks - постраничный список активных или архивных задач с фильтрами из параметров запроса
+func (s *service) listTasks(ctx *fiber.Ctx, owner string, projectID *uuid.UUID, archived bool) error {
+	page, limit := queryPage(ctx)
+
+	filter := repos.TaskFilter{
+		Page:      page,
+		Limit:     limit,
+		Overdue:   ctx.QueryBool("overdue", false),
+		Now:       time.Now(),
+		Tag:       queryTag(ctx),
+		ProjectId: projectID,
+		Assignee:  assigneeOf(ctx.Query("assignee"), owner),
+		Archived:  archived,
+		Fields:    queryFields(ctx),
 	}
 
-	var tasks AllTasksResponse
 	var err error
 
-	tasks.Tasks, err = s.repos.GetAllTasks(ctx.Context(), page, limit)
+	if filter.DueBefore, err = queryTime(ctx, "due_before"); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid due_before parameter, RFC3339 expected")
+	}
+
+	if filter.DueAfter, err = queryTime(ctx, "due_after"); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid due_after parameter, RFC3339 expected")
+	}
+
+	var tasks AllTasksResponse
+
+	tasks.Tasks, err = s.repos.GetAllTasks(ctx.Context(), owner, filter)
 	if err != nil {
 		s.log.Error("Failed to get all tasks", zap.Error(err))
 
@@ -152,13 +323,18 @@ func (s *service) GetAllTasks(ctx *fiber.Ctx) error {
 }
 
 func (s *service) DeleteTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
 		s.log.Error("Invalid id parameter", zap.Error(err))
 		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
 	}
 
-	err = s.repos.DeleteTask(ctx.Context(), id)
+	err = s.repos.DeleteTask(ctx.Context(), owner, id)
 	if err != nil {
 		s.log.Error("Failed to delete task", zap.Error(err))
 
@@ -169,6 +345,7 @@ func (s *service) DeleteTask(ctx *fiber.Ctx) error {
 		return dto.InternalServerError(ctx)
 	}
 
+	// задача в корзине, вложения удаляются только при окончательном удалении
 	response := dto.Response{
 		Status: "success",
 	}
@@ -177,6 +354,11 @@ func (s *service) DeleteTask(ctx *fiber.Ctx) error {
 }
 
 func (s *service) UpdateTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
 	var req UpdateTaskRequest
 
 	// Десериализация JSON-запроса
@@ -196,6 +378,28 @@ func (s *service) UpdateTask(ctx *fiber.Ctx) error {
 		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
 	}
 
+	if err = req.dueValidate(); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	if err = req.parentValidate(); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	if err = req.recurrenceValidate(); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	parentID, err := parseOptionalID(req.ParentID)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid parent_id")
+	}
+
+	projectID, err := parseOptionalID(req.ProjectID)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid project_id")
+	}
+
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
 		s.log.Error("Invalid id parameter", zap.Error(err))
@@ -204,28 +408,57 @@ func (s *service) UpdateTask(ctx *fiber.Ctx) error {
 
 	// Вставка задачи в БД через репозиторий
 	task := repos.UpdateTask{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
+		Title:           req.Title,
+		Description:     req.Description,
+		Status:          req.Status,
+		DueAt:           utc(req.DueAt),
+		ClearDueAt:      req.ClearDueAt,
+		Tags:            req.Tags,
+		ParentId:        parentID,
+		ClearParent:     req.ClearParent,
+		ProjectId:       projectID,
+		ClearRecurrence: req.ClearRecurrence,
+		Estimate:        repos.Estimate{Points: req.Points, Minutes: req.Minutes},
+		ClearPoints:     req.ClearPoints,
+		ClearMinutes:    req.ClearMinutes,
+		Fields:          req.Fields,
 	}
 
 	var newTask TaskResponse
 
-	newTask.Task, err = s.repos.UpdateTask(ctx.Context(), task, id)
+	before, err := s.repos.GetTask(ctx.Context(), owner, id)
 	if err != nil {
-		s.log.Error("Failed to update task", zap.Error(err))
+		s.log.Error("Failed to get task", zap.Error(err))
 
 		if errors.Is(err, myerr.ErrTaskNotFound) {
 			return dto.NotFound(ctx)
 		}
 
-		if errors.Is(err, myerr.ErrTaskNotFound) {
-			return dto.WrongType(ctx)
-		}
-
 		return dto.InternalServerError(ctx)
 	}
 
+	// новая серия начинается с итогового срока задачи
+	due := before.DueAt
+	if task.DueAt != nil {
+		due = task.DueAt
+	} else if task.ClearDueAt {
+		due = nil
+	}
+
+	if task.Recurrence, err = parseRecurrence(req.Recurrence, due); err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	if due == nil && before.Recurrence != nil && !task.ClearRecurrence {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "recurring task requires due_at")
+	}
+
+	newTask.Task, err = s.repos.UpdateTask(ctx.Context(), owner, task, id)
+	if err != nil {
+		s.log.Error("Failed to update task", zap.Error(err))
+		return s.updateTaskError(ctx, err, before.Status, req.Status)
+	}
+
 	// Формирование ответа
 	response := dto.Response{
 		Status: "success",
@@ -234,3 +467,64 @@ func (s *service) UpdateTask(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
+
+// updateTaskError - ответ на ошибку изменения задачи; from и to - статусы для ошибок workflow
+func (s *service) updateTaskError(ctx *fiber.Ctx, err error, from, to string) error {
+	if errors.Is(err, myerr.ErrTaskNotFound) {
+		return dto.NotFound(ctx)
+	}
+
+	if errors.Is(err, myerr.ErrInvalidTaskType) {
+		return dto.WrongType(ctx)
+	}
+
+	if errors.Is(err, myerr.ErrParentNotFound) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "Parent task not found")
+	}
+
+	if errors.Is(err, myerr.ErrTaskCycle) {
+		return dto.Conflict(ctx, dto.TaskCycle, myerr.ErrTaskCycle.Error())
+	}
+
+	if errors.Is(err, myerr.ErrOpenSubtasks) {
+		return dto.Conflict(ctx, dto.OpenSubtasks, myerr.ErrOpenSubtasks.Error())
+	}
+
+	if errors.Is(err, myerr.ErrTaskBlocked) {
+		return dto.Conflict(ctx, dto.TaskBlocked, myerr.ErrTaskBlocked.Error())
+	}
+
+	if errors.Is(err, myerr.ErrUnknownStatus) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, myerr.ErrUnknownStatus.Error()+": "+to)
+	}
+
+	if errors.Is(err, myerr.ErrTransition) {
+		return dto.Conflict(ctx, dto.InvalidTransition, myerr.ErrTransition.Error()+": "+from+" -> "+to)
+	}
+
+	if errors.Is(err, myerr.ErrProjectNotFound) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "Project not found")
+	}
+
+	if errors.Is(err, myerr.ErrProjectArchived) {
+		return dto.Conflict(ctx, dto.ProjectArchived, myerr.ErrProjectArchived.Error())
+	}
+
+	var fieldErr *myerr.FieldValueError
+	if errors.As(err, &fieldErr) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, fieldErr.Error())
+	}
+
+	return dto.InternalServerError(ctx)
+}
+
+// utc - время в UTC, чтобы одинаково хранилось в обоих хранилищах
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+
+	return &u
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+)
+
+func (s *service) GetTags(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	var tags TagsResponse
+	var err error
+
+	tags.Tags, err = s.repos.TagCounts(ctx.Context(), owner)
+	if err != nil {
+		s.log.Error("Failed to count tags", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   tags,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
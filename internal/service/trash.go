@@ -0,0 +1,110 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// GetTrash - удалённые задачи, последние удалённые сверху
+func (s *service) GetTrash(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	page, limit := queryPage(ctx)
+
+	var trash TrashResponse
+
+	tasks, err := s.repos.ListTrash(ctx.Context(), owner, page, limit)
+	if err != nil {
+		s.log.Error("Failed to list trash", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrRange) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	trash.Tasks = tasks
+
+	response := dto.Response{
+		Status: "success",
+		Data:   trash,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// RestoreTask - возвращает задачу из корзины вместе с комментариями, вложениями и зависимостями
+func (s *service) RestoreTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var task TaskResponse
+
+	task.Task, err = s.repos.RestoreTask(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to restore task", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// PurgeTask - окончательное удаление задачи из корзины вместе с содержимым вложений
+func (s *service) PurgeTask(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	_, files, err := s.repos.PurgeTask(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to purge task", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	s.removeBlobs(ctx.Context(), files)
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
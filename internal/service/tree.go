@@ -0,0 +1,90 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+func (s *service) GetChildren(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var children ChildrenResponse
+
+	children.Children, err = s.repos.GetChildren(ctx.Context(), owner, id)
+	if err != nil {
+		s.log.Error("Failed to get children", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   children,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// getTaskTree - ответ GET /task/:id?tree=true: задача со всеми подзадачами
+func (s *service) getTaskTree(ctx *fiber.Ctx, owner string, task repos.Task) error {
+	descendants, err := s.repos.GetDescendants(ctx.Context(), owner, task.Id)
+	if err != nil {
+		s.log.Error("Failed to get descendants", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrTaskNotFound) {
+			return dto.NotFound(ctx)
+		}
+
+		return dto.InternalServerError(ctx)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   buildTree(task, descendants),
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// buildTree - сборка дерева из корня и плоского списка потомков
+func buildTree(root repos.Task, descendants []repos.Task) *TaskNode {
+	nodes := make(map[uuid.UUID]*TaskNode, len(descendants)+1)
+
+	rootNode := &TaskNode{Task: root, Children: []*TaskNode{}}
+	nodes[root.Id] = rootNode
+
+	for _, t := range descendants {
+		nodes[t.Id] = &TaskNode{Task: t, Children: []*TaskNode{}}
+	}
+
+	for _, t := range descendants {
+		if t.ParentId == nil {
+			continue
+		}
+
+		if parent, ok := nodes[*t.ParentId]; ok {
+			parent.Children = append(parent.Children, nodes[t.Id])
+		}
+	}
+
+	return rootNode
+}
@@ -1,12 +1,55 @@
 package service
 
-import "github.com/pkg/errors"
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
 
 func (u UpdateTaskRequest) updateTaskValidate() error {
-	if u.Title == "" && u.Description == "" && u.Status == "" {
-		err := errors.New("title or description or status is required")
+	if u.Title == "" && u.Description == "" && u.Status == "" && u.DueAt == nil && !u.ClearDueAt && u.Tags == nil &&
+		u.ParentID == "" && !u.ClearParent && u.ProjectID == "" && u.Recurrence == nil && !u.ClearRecurrence &&
+		u.Points == nil && u.Minutes == nil && !u.ClearPoints && !u.ClearMinutes && u.Fields == nil {
+		err := errors.New("title or description or status or due_at or tags or parent_id or project_id or recurrence or estimate or fields is required")
 		return errors.Wrap(err, "not validate request to update task")
 	}
 
 	return nil
 }
+
+func (u UpdateTaskRequest) dueValidate() error {
+	if u.DueAt != nil && u.ClearDueAt {
+		return errors.New("due_at and clear_due_at can not be used together")
+	}
+
+	return nil
+}
+
+func (u UpdateTaskRequest) parentValidate() error {
+	if u.ParentID != "" && u.ClearParent {
+		return errors.New("parent_id and clear_parent can not be used together")
+	}
+
+	return nil
+}
+
+func (u UpdateTaskRequest) recurrenceValidate() error {
+	if u.Recurrence != nil && u.ClearRecurrence {
+		return errors.New("recurrence and clear_recurrence can not be used together")
+	}
+
+	return nil
+}
+
+// parseOptionalID - необязательный идентификатор из тела запроса
+func parseOptionalID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/volkowlad/week4/internal/dto"
+)
+
+// GetWorkflow - статусы задач и разрешённые переходы между ними
+func (s *service) GetWorkflow(ctx *fiber.Ctx) error {
+	response := dto.Response{
+		Status: "success",
+		Data:   WorkflowResponse{Workflow: s.workflow.Definition()},
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/dto"
+	"github.com/volkowlad/week4/internal/myerr"
+	"github.com/volkowlad/week4/internal/repos"
+	"github.com/volkowlad/week4/pkg/validator"
+)
+
+// StartTimer - запускает таймер пользователя по задаче; тело с заметкой необязательно
+func (s *service) StartTimer(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	req, err := timerRequest(ctx)
+	if err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	var worklog WorklogResponse
+
+	worklog.Worklog, err = s.repos.StartTimer(ctx.Context(), owner, repos.Worklog{
+		Id:     uuid.New(),
+		TaskId: taskID,
+		User:   owner,
+		Note:   req.Note,
+	})
+	if err != nil {
+		s.log.Error("Failed to start timer", zap.Error(err))
+		return s.worklogError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// StopTimer - останавливает запущенный таймер пользователя по задаче; заметка из тела заменяет прежнюю
+func (s *service) StopTimer(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	req, err := timerRequest(ctx)
+	if err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	var worklog WorklogResponse
+
+	worklog.Worklog, err = s.repos.StopTimer(ctx.Context(), owner, taskID, owner, req.Note)
+	if err != nil {
+		s.log.Error("Failed to stop timer", zap.Error(err))
+		return s.worklogError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) AddWorklog(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	var req WorklogRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var worklog WorklogResponse
+
+	worklog.Worklog, err = s.repos.AddWorklog(ctx.Context(), owner, repos.Worklog{
+		Id:     uuid.New(),
+		TaskId: taskID,
+		User:   owner,
+		Start:  *utc(req.Start),
+		End:    utc(req.End),
+		Note:   req.Note,
+	})
+	if err != nil {
+		s.log.Error("Failed to add worklog", zap.Error(err))
+		return s.worklogError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// GetWorklogs - записи времени по задаче и итоги по пользователям
+func (s *service) GetWorklogs(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		s.log.Error("Invalid id parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid id parameter")
+	}
+
+	page, limit := queryPage(ctx)
+
+	var worklogs AllWorklogsResponse
+
+	worklogs.Worklogs, worklogs.Totals, err = s.repos.ListWorklogs(ctx.Context(), owner, taskID, page, limit)
+	if err != nil {
+		s.log.Error("Failed to list worklogs", zap.Error(err))
+
+		if errors.Is(err, myerr.ErrRange) {
+			return dto.NotFound(ctx)
+		}
+
+		return s.worklogError(ctx, err)
+	}
+
+	for _, t := range worklogs.Totals {
+		worklogs.Total += t.Duration
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   worklogs,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) UpdateWorklog(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, worklogID, err := worklogParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	var req UpdateWorklogRequest
+
+	if err = json.Unmarshal(ctx.Body(), &req); err != nil {
+		s.log.Error("Invalid request body", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	}
+
+	var worklog WorklogResponse
+
+	worklog.Worklog, err = s.repos.UpdateWorklog(ctx.Context(), owner, taskID, worklogID, repos.WorklogUpdate{
+		Start: utc(req.Start),
+		End:   utc(req.End),
+		Note:  req.Note,
+	})
+	if err != nil {
+		s.log.Error("Failed to update worklog", zap.Error(err))
+		return s.worklogError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (s *service) DeleteWorklog(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	taskID, worklogID, err := worklogParams(ctx)
+	if err != nil {
+		s.log.Error("Invalid path parameter", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, err.Error())
+	}
+
+	if err = s.repos.DeleteWorklog(ctx.Context(), owner, taskID, worklogID); err != nil {
+		s.log.Error("Failed to delete worklog", zap.Error(err))
+		return s.worklogError(ctx, err)
+	}
+
+	response := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetWorklogReport - время по задачам за период [from, to); записи на границе периода учитываются частично
+func (s *service) GetWorklogReport(ctx *fiber.Ctx) error {
+	owner, ok := caller(ctx)
+	if !ok {
+		return dto.UnauthorizedResponse(ctx)
+	}
+
+	from, err := queryTime(ctx, "from")
+	if err != nil || from == nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid from parameter, RFC3339 expected")
+	}
+
+	to, err := queryTime(ctx, "to")
+	if err != nil || to == nil {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid to parameter, RFC3339 expected")
+	}
+
+	if !to.After(*from) {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "to must be after from")
+	}
+
+	report := WorklogReportResponse{
+		From: from.UTC(),
+		To:   to.UTC(),
+	}
+
+	report.Tasks, err = s.repos.WorklogReport(ctx.Context(), owner, report.From, report.To)
+	if err != nil {
+		s.log.Error("Failed to build worklog report", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	for _, t := range report.Tasks {
+		report.Total += t.Duration
+	}
+
+	response := dto.Response{
+		Status: "success",
+		Data:   report,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// timerRequest - тело запроса к таймеру, пустое тело допустимо
+func timerRequest(ctx *fiber.Ctx) (TimerRequest, error) {
+	var req TimerRequest
+
+	if len(ctx.Body()) == 0 {
+		return req, nil
+	}
+
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return req, errors.New("Invalid request body")
+	}
+
+	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
+		return req, vErr
+	}
+
+	return req, nil
+}
+
+// worklogParams - id задачи и записи времени из пути
+func worklogParams(ctx *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	taskID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid id parameter")
+	}
+
+	worklogID, err := uuid.Parse(ctx.Params("wid"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid wid parameter")
+	}
+
+	return taskID, worklogID, nil
+}
+
+func (s *service) worklogError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, myerr.ErrTaskNotFound), errors.Is(err, myerr.ErrWorklogNotFound):
+		return dto.NotFound(ctx)
+	case errors.Is(err, myerr.ErrWorklogAuthor):
+		return dto.Forbidden(ctx, dto.NotWorklogAuthor, myerr.ErrWorklogAuthor.Error())
+	case errors.Is(err, myerr.ErrTimerRunning):
+		return dto.Conflict(ctx, dto.TimerRunning, myerr.ErrTimerRunning.Error())
+	case errors.Is(err, myerr.ErrNoTimer):
+		return dto.Conflict(ctx, dto.NoTimer, myerr.ErrNoTimer.Error())
+	case errors.Is(err, myerr.ErrWorklogRange):
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, myerr.ErrWorklogRange.Error())
+	}
+
+	return dto.InternalServerError(ctx)
+}
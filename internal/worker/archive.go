@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// Archiver - периодически переносит в архив задачи, выполненные дольше after назад
+type Archiver struct {
+	repos    repos.Repository
+	after    time.Duration
+	interval time.Duration
+	log      *zap.SugaredLogger
+}
+
+// NewArchiver - конструктор архивирования выполненных задач
+func NewArchiver(repos repos.Repository, after, interval time.Duration, logger *zap.SugaredLogger) *Archiver {
+	return &Archiver{
+		repos:    repos,
+		after:    after,
+		interval: interval,
+		log:      logger,
+	}
+}
+
+// Run - работает до отмены ctx
+func (w *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.archive(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Archiver) archive(ctx context.Context) {
+	tasks, err := w.repos.ArchiveTasks(ctx, time.Now().Add(-w.after))
+	if err != nil {
+		w.log.Errorw("Failed to archive tasks", "error", err)
+		return
+	}
+
+	for _, t := range tasks {
+		w.log.Infow("Task archived", "task_id", t.Id, "owner_id", t.OwnerId, "title", t.Title, "completed_at", t.CompletedAt)
+	}
+}
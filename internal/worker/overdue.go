@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// OverdueSweeper - периодически помечает просроченные невыполненные задачи и пишет их в лог
+type OverdueSweeper struct {
+	repos    repos.Repository
+	interval time.Duration
+	log      *zap.SugaredLogger
+}
+
+// NewOverdueSweeper - конструктор обработчика просроченных задач
+func NewOverdueSweeper(repos repos.Repository, interval time.Duration, logger *zap.SugaredLogger) *OverdueSweeper {
+	return &OverdueSweeper{
+		repos:    repos,
+		interval: interval,
+		log:      logger,
+	}
+}
+
+// Run - работает до отмены ctx
+func (w *OverdueSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *OverdueSweeper) sweep(ctx context.Context) {
+	tasks, err := w.repos.FlagOverdueTasks(ctx, time.Now())
+	if err != nil {
+		w.log.Errorw("Failed to flag overdue tasks", "error", err)
+		return
+	}
+
+	for _, t := range tasks {
+		w.log.Warnw("Task is overdue", "task_id", t.Id, "owner_id", t.OwnerId, "title", t.Title, "due_at", t.DueAt)
+	}
+}
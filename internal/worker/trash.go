@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/volkowlad/week4/internal/blob"
+	"github.com/volkowlad/week4/internal/repos"
+)
+
+// TrashPurger - периодически удаляет окончательно задачи, пролежавшие в корзине дольше срока хранения
+type TrashPurger struct {
+	repos     repos.Repository
+	files     blob.Store
+	retention time.Duration
+	interval  time.Duration
+	log       *zap.SugaredLogger
+}
+
+// NewTrashPurger - конструктор очистки корзины
+func NewTrashPurger(repos repos.Repository, files blob.Store, retention, interval time.Duration, logger *zap.SugaredLogger) *TrashPurger {
+	return &TrashPurger{
+		repos:     repos,
+		files:     files,
+		retention: retention,
+		interval:  interval,
+		log:       logger,
+	}
+}
+
+// Run - работает до отмены ctx
+func (w *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *TrashPurger) purge(ctx context.Context) {
+	tasks, files, err := w.repos.PurgeTrash(ctx, time.Now().Add(-w.retention))
+	if err != nil {
+		w.log.Errorw("Failed to purge trash", "error", err)
+		return
+	}
+
+	// содержимое вложений удаляется после записи в базу: лишний файл лучше потерянного
+	for _, a := range files {
+		if err = w.files.Delete(ctx, a.BlobKey()); err != nil {
+			w.log.Errorw("Failed to delete attachment blob", "key", a.BlobKey(), "error", err)
+		}
+	}
+
+	for _, t := range tasks {
+		w.log.Infow("Task purged from trash", "task_id", t.Id, "owner_id", t.OwnerId, "title", t.Title, "deleted_at", t.DeletedAt)
+	}
+}
@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// Workflow - конечный автомат статусов задачи; один экземпляр на оба хранилища
+type Workflow struct {
+	statuses    []string
+	done        []string
+	known       map[string]bool
+	isDone      map[string]bool
+	transitions map[string]map[string]bool
+}
+
+// Definition - описание автомата для клиентов
+type Definition struct {
+	Initial     string              `json:"initial"`
+	Statuses    []string            `json:"statuses"`
+	Done        []string            `json:"done"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// New - проверяет конфигурацию: статусы уникальны, переходы и выполненные статусы ссылаются на известные
+func New(cfg config.Workflow) (*Workflow, error) {
+	if len(cfg.Statuses) == 0 {
+		return nil, errors.New("workflow has no statuses")
+	}
+
+	if len(cfg.Done) == 0 {
+		return nil, errors.New("workflow has no done statuses")
+	}
+
+	w := &Workflow{
+		known:       make(map[string]bool),
+		isDone:      make(map[string]bool),
+		transitions: make(map[string]map[string]bool),
+	}
+
+	for _, status := range cfg.Statuses {
+		if status == "" || w.known[status] {
+			return nil, errors.Errorf("workflow status %q is empty or duplicated", status)
+		}
+
+		w.known[status] = true
+		w.statuses = append(w.statuses, status)
+	}
+
+	for _, status := range cfg.Done {
+		if !w.known[status] {
+			return nil, errors.Errorf("done status %q is not in workflow statuses", status)
+		}
+
+		if status == w.Initial() {
+			return nil, errors.Errorf("initial status %q can not be a done status", status)
+		}
+
+		w.isDone[status] = true
+		w.done = append(w.done, status)
+	}
+
+	for from, targets := range cfg.Transitions {
+		if !w.known[from] {
+			return nil, errors.Errorf("transition from unknown status %q", from)
+		}
+
+		w.transitions[from] = make(map[string]bool)
+		for _, to := range targets {
+			if !w.known[to] {
+				return nil, errors.Errorf("transition %s -> %s: unknown status %q", from, to, to)
+			}
+
+			w.transitions[from][to] = true
+		}
+	}
+
+	return w, nil
+}
+
+// Initial - статус новой задачи
+func (w *Workflow) Initial() string {
+	return w.statuses[0]
+}
+
+// IsDone - задача в этом статусе считается выполненной
+func (w *Workflow) IsDone(status string) bool {
+	return w.isDone[status]
+}
+
+// Done - выполненные статусы, для запросов к БД
+func (w *Workflow) Done() []string {
+	return w.done
+}
+
+// Check - можно ли перевести задачу из from в to; повтор текущего статуса разрешён
+func (w *Workflow) Check(from, to string) error {
+	if !w.known[to] {
+		return errors.Wrapf(myerr.ErrUnknownStatus, "%q", to)
+	}
+
+	if from == to || w.transitions[from][to] {
+		return nil
+	}
+
+	return errors.Wrapf(myerr.ErrTransition, "%s -> %s", from, to)
+}
+
+// Definition - статусы и переходы в порядке из конфигурации
+func (w *Workflow) Definition() Definition {
+	def := Definition{
+		Initial:     w.Initial(),
+		Statuses:    w.statuses,
+		Done:        w.done,
+		Transitions: make(map[string][]string),
+	}
+
+	for _, from := range w.statuses {
+		targets := make([]string, 0)
+		for _, to := range w.statuses {
+			if w.transitions[from][to] {
+				targets = append(targets, to)
+			}
+		}
+
+		def.Transitions[from] = targets
+	}
+
+	return def
+}
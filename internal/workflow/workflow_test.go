@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+
+	"github.com/volkowlad/week4/internal/config"
+	"github.com/volkowlad/week4/internal/myerr"
+)
+
+// defaultWorkflow - автомат из значений по умолчанию WORKFLOW_*
+func defaultWorkflow(t *testing.T) *Workflow {
+	t.Helper()
+
+	var cfg config.Workflow
+	if err := envconfig.Process("", &cfg); err != nil {
+		t.Fatalf("load workflow config: %v", err)
+	}
+
+	w, err := New(cfg)
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+
+	return w
+}
+
+func TestNewErrors(t *testing.T) {
+	statuses := []string{"new", "done"}
+
+	tests := []struct {
+		name string
+		cfg  config.Workflow
+	}{
+		{"no statuses", config.Workflow{Done: []string{"done"}}},
+		{"no done statuses", config.Workflow{Statuses: statuses}},
+		{"duplicate status", config.Workflow{Statuses: []string{"new", "done", "new"}, Done: []string{"done"}}},
+		{"empty status", config.Workflow{Statuses: []string{"new", "", "done"}, Done: []string{"done"}}},
+		{"unknown done status", config.Workflow{Statuses: statuses, Done: []string{"closed"}}},
+		{"done is initial", config.Workflow{Statuses: statuses, Done: []string{"new"}}},
+		{"transition from undeclared status", config.Workflow{Statuses: statuses, Done: []string{"done"},
+			Transitions: config.Transitions{"review": {"done"}}}},
+		{"transition to undeclared status", config.Workflow{Statuses: statuses, Done: []string{"done"},
+			Transitions: config.Transitions{"new": {"done", "review"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, err := New(tt.cfg); err == nil {
+				t.Fatalf("invalid config accepted: %+v", w.Definition())
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	w := defaultWorkflow(t)
+
+	tests := []struct {
+		from, to string
+		err      error
+	}{
+		{"new", "in_progress", nil},
+		{"new", "done", nil},
+		{"in_progress", "new", nil},
+		{"done", "reopened", nil},
+		{"reopened", "done", nil},
+		{"done", "done", nil}, // повтор текущего статуса
+		{"done", "new", myerr.ErrTransition},
+		{"done", "in_progress", myerr.ErrTransition},
+		{"new", "reopened", myerr.ErrTransition},
+		{"new", "closed", myerr.ErrUnknownStatus},
+		{"new", "", myerr.ErrUnknownStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			err := w.Check(tt.from, tt.to)
+			if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("Check(%q, %q) = %v, want %v", tt.from, tt.to, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	w := defaultWorkflow(t)
+
+	if w.Initial() != "new" || !w.IsDone("done") || w.IsDone("reopened") {
+		t.Fatalf("initial %q, done %v", w.Initial(), w.Done())
+	}
+
+	want := Definition{
+		Initial:  "new",
+		Statuses: []string{"new", "in_progress", "done", "reopened"},
+		Done:     []string{"done"},
+		Transitions: map[string][]string{
+			"new":         {"in_progress", "done"},
+			"in_progress": {"new", "done"},
+			"done":        {"reopened"},
+			"reopened":    {"in_progress", "done"},
+		},
+	}
+
+	if got := w.Definition(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("definition %+v, want %+v", got, want)
+	}
+}
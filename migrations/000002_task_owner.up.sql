@@ -0,0 +1,5 @@
+-- Владелец задачи; существующие задачи создавались по статическому токену
+ALTER TABLE tasks ADD COLUMN owner_id TEXT NOT NULL DEFAULT 'static-token';
+ALTER TABLE tasks ALTER COLUMN owner_id DROP DEFAULT;
+
+CREATE INDEX idx_tasks_owner_id ON tasks (owner_id);
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+                          id UUID PRIMARY KEY,                 -- Идентификатор ключа
+                          name TEXT NOT NULL,                  -- Название ключа
+                          subject TEXT NOT NULL,               -- От чьего имени действует ключ
+                          prefix TEXT NOT NULL,                -- Начало ключа для отображения
+                          key_hash TEXT NOT NULL UNIQUE,       -- SHA-256 от ключа, сам ключ не хранится
+                          scopes TEXT[] NOT NULL DEFAULT '{}', -- Выданные scope
+                          expires_at TIMESTAMP,                -- Срок действия (NULL - бессрочный)
+                          last_used_at TIMESTAMP,              -- Время последнего использования
+                          revoked_at TIMESTAMP,                -- Время отзыва
+                          created_at TIMESTAMP DEFAULT now()   -- Время создания
+);
@@ -0,0 +1,17 @@
+-- Журнал аудита изменений задач. Снимки хранятся в JSON (не JSONB),
+-- чтобы текст совпадал байт в байт с тем, от которого посчитан хеш.
+CREATE TABLE audit_log (
+                           seq BIGSERIAL PRIMARY KEY,          -- Порядковый номер записи
+                           task_id UUID NOT NULL,              -- Задача
+                           actor TEXT NOT NULL,                -- Кто выполнил действие
+                           action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+                           before JSON,                        -- Снимок задачи до изменения
+                           after JSON,                         -- Снимок задачи после изменения
+                           created_at TIMESTAMPTZ NOT NULL,    -- Время записи
+                           prev_hash TEXT NOT NULL,            -- Хеш предыдущей записи
+                           hash TEXT NOT NULL                  -- Хеш этой записи
+);
+
+CREATE INDEX idx_audit_log_task_id ON audit_log (task_id);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_due_at;
+ALTER TABLE tasks DROP COLUMN overdue;
+ALTER TABLE tasks DROP COLUMN due_at;
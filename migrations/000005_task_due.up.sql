@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ;                     -- Срок выполнения (необязательный)
+ALTER TABLE tasks ADD COLUMN overdue BOOLEAN NOT NULL DEFAULT false; -- Помечена как просроченная
+
+-- Поиск просроченных задач фоновым обработчиком и фильтры по сроку
+CREATE INDEX idx_tasks_due_at ON tasks (due_at) WHERE status <> 'done';
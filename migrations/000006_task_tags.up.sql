@@ -0,0 +1,12 @@
+CREATE TABLE tags (
+                      id SERIAL PRIMARY KEY,     -- Идентификатор тега
+                      name TEXT NOT NULL UNIQUE  -- Тег вида #name
+);
+
+CREATE TABLE task_tags (
+                           task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+                           tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+                           PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX idx_task_tags_tag_id ON task_tags (tag_id);
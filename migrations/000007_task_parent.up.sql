@@ -0,0 +1,4 @@
+-- Подзадачи: при удалении родителя подзадачи становятся задачами верхнего уровня
+ALTER TABLE tasks ADD COLUMN parent_id UUID REFERENCES tasks (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tasks_parent_id ON tasks (parent_id);
@@ -0,0 +1,14 @@
+-- Блокирующие зависимости: task_id нельзя начать, пока не выполнена blocker_id
+CREATE TABLE task_dependencies (
+    task_id    UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    blocker_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+
+CREATE INDEX idx_task_dependencies_blocker_id ON task_dependencies (blocker_id);
+
+-- В журнал аудита попадают и изменения зависимостей
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'dependency_add', 'dependency_remove'));
@@ -0,0 +1,18 @@
+-- Комментарии к задачам удаляются вместе с задачей
+CREATE TABLE task_comments (
+    id         UUID PRIMARY KEY,
+    task_id    UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    author     TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    edited_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_task_comments_task_id ON task_comments (task_id, created_at, id);
+
+-- В журнал аудита попадают и изменения комментариев
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'dependency_add', 'dependency_remove'));
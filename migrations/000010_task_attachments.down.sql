@@ -0,0 +1,9 @@
+-- Записи журнала не удаляются, иначе цепочка хешей рвётся: прежнее ограничение
+-- возвращается как NOT VALID и проверяет только новые записи
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'dependency_add', 'dependency_remove')) NOT VALID;
+
+DROP TABLE IF EXISTS task_attachments;
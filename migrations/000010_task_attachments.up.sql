@@ -0,0 +1,20 @@
+-- Метаданные вложений; содержимое файлов хранится в blob-хранилище (каталог или S3)
+CREATE TABLE task_attachments (
+    id           UUID PRIMARY KEY,
+    task_id      UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    size         BIGINT NOT NULL CHECK (size >= 0),
+    content_type TEXT NOT NULL,
+    sha256       CHAR(64) NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_task_attachments_task_id ON task_attachments (task_id, created_at, id);
+
+-- В журнал аудита попадают и изменения вложений
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'attachment_add', 'attachment_delete',
+                      'dependency_add', 'dependency_remove'));
@@ -0,0 +1,24 @@
+-- Проекты владельца; ключ уникален в пределах владельца
+CREATE TABLE projects (
+    id          UUID PRIMARY KEY,
+    owner_id    TEXT NOT NULL,
+    key         TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    archived    BOOLEAN NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (owner_id, key)
+);
+
+-- Существующие задачи переезжают в проект по умолчанию своего владельца
+INSERT INTO projects (id, owner_id, key, name)
+SELECT gen_random_uuid(), owner_id, 'DEFAULT', 'Default' FROM (SELECT DISTINCT owner_id FROM tasks) owners;
+
+ALTER TABLE tasks ADD COLUMN project_id UUID REFERENCES projects (id);
+
+UPDATE tasks SET project_id = p.id FROM projects p WHERE p.owner_id = tasks.owner_id AND p.key = 'DEFAULT';
+
+ALTER TABLE tasks ALTER COLUMN project_id SET NOT NULL;
+
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
@@ -0,0 +1,10 @@
+-- Исполнители задач (subject из токена, сертификата или API-ключа)
+CREATE TABLE task_assignees (
+    task_id     UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    assignee    TEXT NOT NULL,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, assignee)
+);
+
+-- Для фильтра ?assignee= / ?assignee=me
+CREATE INDEX idx_task_assignees_assignee ON task_assignees (assignee, task_id);
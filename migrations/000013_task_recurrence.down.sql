@@ -0,0 +1,6 @@
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_recurrence_check;
+ALTER TABLE tasks DROP COLUMN recurrence_next_id;
+ALTER TABLE tasks DROP COLUMN recurrence_seq;
+ALTER TABLE tasks DROP COLUMN recurrence_start;
+ALTER TABLE tasks DROP COLUMN recurrence_tz;
+ALTER TABLE tasks DROP COLUMN recurrence_rule;
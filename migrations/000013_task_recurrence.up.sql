@@ -0,0 +1,12 @@
+-- Повторяющиеся задачи: правило RRULE и положение задачи в серии
+ALTER TABLE tasks ADD COLUMN recurrence_rule TEXT;                  -- Каноническое правило, NULL - не повторяется
+ALTER TABLE tasks ADD COLUMN recurrence_tz TEXT;                    -- Часовой пояс IANA для расчёта дат
+ALTER TABLE tasks ADD COLUMN recurrence_start TIMESTAMPTZ;          -- Срок первого повторения серии
+ALTER TABLE tasks ADD COLUMN recurrence_seq INTEGER;                -- Номер повторения, с 1
+ALTER TABLE tasks ADD COLUMN recurrence_next_id UUID REFERENCES tasks (id) ON DELETE SET NULL; -- Созданное следующее повторение
+
+-- Даты повторений считаются от срока, поэтому без срока правило не имеет смысла
+ALTER TABLE tasks ADD CONSTRAINT tasks_recurrence_check CHECK (
+    recurrence_rule IS NULL OR (recurrence_tz IS NOT NULL AND recurrence_start IS NOT NULL
+        AND recurrence_seq >= 1 AND due_at IS NOT NULL)
+);
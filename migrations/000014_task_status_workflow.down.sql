@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_due_at;
+CREATE INDEX idx_tasks_due_at ON tasks (due_at) WHERE status <> 'done';
+
+-- Задачи в статусах, которых нет в исходном наборе, нужно перевести до отката
+ALTER TABLE tasks ALTER COLUMN status DROP NOT NULL;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check CHECK (status IN ('new', 'in_progress', 'done'));
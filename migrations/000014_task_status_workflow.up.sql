@@ -0,0 +1,7 @@
+-- Статусы задаются конфигурацией (WORKFLOW_*), переходы проверяет приложение
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ALTER COLUMN status SET NOT NULL;
+
+-- Выполненные статусы передаются параметром, частичный индекс по 'done' больше не подходит
+DROP INDEX IF EXISTS idx_tasks_due_at;
+CREATE INDEX idx_tasks_due_at ON tasks (due_at) WHERE NOT overdue;
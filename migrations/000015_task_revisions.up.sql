@@ -0,0 +1,19 @@
+-- История задачи: снимок после каждого изменения, записи не меняются
+CREATE TABLE task_revisions (
+    task_id    UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    rev        INTEGER NOT NULL CHECK (rev >= 1), -- Номер изменения задачи
+    actor      TEXT NOT NULL,                     -- Кто изменил
+    snapshot   JSONB NOT NULL,                    -- Задача после изменения
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, rev)
+);
+
+CREATE FUNCTION task_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'task revisions can not be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Удаление допускается только каскадом вместе с задачей
+CREATE TRIGGER task_revisions_no_update BEFORE UPDATE ON task_revisions
+    FOR EACH ROW EXECUTE FUNCTION task_revisions_immutable();
@@ -0,0 +1,12 @@
+-- Задачи из корзины при откате не удаляются: без deleted_at они снова становятся живыми
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
+
+-- Записи журнала не удаляются, иначе цепочка хешей рвётся: прежнее ограничение
+-- возвращается как NOT VALID и проверяет только новые записи
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'attachment_add', 'attachment_delete',
+                      'dependency_add', 'dependency_remove')) NOT VALID;
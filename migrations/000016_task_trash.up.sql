@@ -0,0 +1,12 @@
+-- Удалённые задачи попадают в корзину и удаляются окончательно по истечении срока хранения
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ; -- Время переноса в корзину, NULL - задача не удалена
+
+CREATE INDEX idx_tasks_deleted_at ON tasks (owner_id, deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- В журнал аудита попадают восстановление и окончательное удаление
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'attachment_add', 'attachment_delete',
+                      'dependency_add', 'dependency_remove'));
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_completed_at;
+DROP INDEX IF EXISTS idx_tasks_archived;
+DROP INDEX IF EXISTS idx_tasks_active;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_at;
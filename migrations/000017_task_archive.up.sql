@@ -0,0 +1,12 @@
+-- Выполненные задачи через ARCHIVE_AFTER уходят в архив и не попадают в обычные списки
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMPTZ; -- Переход в выполненный статус, NULL - задача не выполнена
+ALTER TABLE tasks ADD COLUMN archived_at TIMESTAMPTZ;  -- Перенос в архив, NULL - задача активна
+
+-- Для уже выполненных задач время выполнения неизвестно, берём последнее изменение.
+-- Набор выполненных статусов задаётся конфигурацией, здесь - значение по умолчанию.
+UPDATE tasks SET completed_at = updated_at WHERE status = 'done';
+
+-- Списки идут по владельцу в порядке создания; архив растёт, а активные задачи читаются по своему индексу
+CREATE INDEX idx_tasks_active ON tasks (owner_id, created_at, id) WHERE archived_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX idx_tasks_archived ON tasks (owner_id, created_at, id) WHERE archived_at IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX idx_tasks_completed_at ON tasks (completed_at) WHERE archived_at IS NULL AND deleted_at IS NULL;
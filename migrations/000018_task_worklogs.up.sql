@@ -0,0 +1,25 @@
+-- Учёт времени по задачам, записи удаляются вместе с задачей
+CREATE TABLE task_worklogs (
+    id         UUID PRIMARY KEY,
+    task_id    UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id    TEXT NOT NULL,          -- Кто работал
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at   TIMESTAMPTZ,            -- NULL - таймер запущен
+    note       TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT task_worklogs_range_check CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+CREATE INDEX idx_task_worklogs_task_id ON task_worklogs (task_id, started_at, id);
+CREATE INDEX idx_task_worklogs_started_at ON task_worklogs (started_at);
+-- Не больше одного запущенного таймера у пользователя
+CREATE UNIQUE INDEX idx_task_worklogs_running ON task_worklogs (user_id) WHERE ended_at IS NULL;
+
+-- В журнал аудита попадают и изменения учёта времени
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'attachment_add', 'attachment_delete',
+                      'worklog_add', 'worklog_update', 'worklog_delete',
+                      'dependency_add', 'dependency_remove'));
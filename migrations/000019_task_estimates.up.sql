@@ -0,0 +1,3 @@
+-- Оценки задач, NULL - задача не оценена
+ALTER TABLE tasks ADD COLUMN estimate_points INT CHECK (estimate_points >= 0);   -- Story points
+ALTER TABLE tasks ADD COLUMN estimate_minutes INT CHECK (estimate_minutes >= 0); -- Оценка во времени
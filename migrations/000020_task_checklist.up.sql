@@ -0,0 +1,20 @@
+-- Чек-лист задачи, пункты удаляются вместе с задачей
+CREATE TABLE task_checklist (
+    id       UUID PRIMARY KEY,
+    task_id  UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    text     TEXT NOT NULL,
+    done     BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL CHECK (position >= 1) -- Порядок пунктов, с 1 без пропусков
+);
+
+CREATE INDEX idx_task_checklist_task_id ON task_checklist (task_id, position);
+
+-- В журнал аудита попадают и изменения чек-листа
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge',
+                      'comment_add', 'comment_update', 'comment_delete',
+                      'attachment_add', 'attachment_delete',
+                      'checklist',
+                      'worklog_add', 'worklog_update', 'worklog_delete',
+                      'dependency_add', 'dependency_remove'));
@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS custom_fields;
+DROP TABLE IF EXISTS project_fields;
@@ -0,0 +1,20 @@
+-- Пользовательские поля задач проекта
+CREATE TABLE project_fields (
+    id         UUID PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    key        TEXT NOT NULL,                    -- Ключ в значениях задачи
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL CHECK (type IN ('string', 'number', 'enum', 'date', 'bool')),
+    required   BOOLEAN NOT NULL DEFAULT false,
+    min_value  DOUBLE PRECISION,                 -- string - длина, number - значение
+    max_value  DOUBLE PRECISION,
+    min_date   DATE,                             -- Границы для date
+    max_date   DATE,
+    options    TEXT[] NOT NULL DEFAULT '{}',     -- Допустимые значения enum
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (project_id, key)
+);
+
+-- Значения полей, ключи - из project_fields проекта задачи
+ALTER TABLE tasks ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}';
@@ -19,6 +19,8 @@ const (
 	ErrFieldBelowMinLen   = "Field is below minimum length"
 	ErrFieldExceedsMaxVal = "Field exceeds maximum value"
 	ErrFieldBelowMinVal   = "Field is below minimum value"
+	ErrFieldNotAllowed    = "Field is not one of the allowed values"
+	ErrUnknownField       = "Unknown field"
 	ErrUnknownValidation  = "Unknown validation error"
 )
 
@@ -29,6 +31,8 @@ func init() {
 func New() *validator.Validate {
 	v := validator.New()
 	_ = v.RegisterValidation("tag", validateTag)
+	_ = v.RegisterValidation("projectkey", validateProjectKey)
+	_ = v.RegisterValidation("fieldkey", validateFieldKey)
 
 	return v
 }
@@ -46,11 +50,33 @@ func validateTag(fl validator.FieldLevel) bool {
 	return re.MatchString(fl.Field().String())
 }
 
+// validateProjectKey - ключ проекта: латинские заглавные буквы и цифры, начинается с буквы, 2-10 символов
+func validateProjectKey(fl validator.FieldLevel) bool {
+	re, _ := regexp.Compile(`^[A-Z][A-Z0-9]{1,9}$`)
+	return re.MatchString(fl.Field().String())
+}
+
+// validateFieldKey - ключ пользовательского поля: латинские строчные буквы, цифры и "_", начинается с буквы, до 40 символов
+func validateFieldKey(fl validator.FieldLevel) bool {
+	re, _ := regexp.Compile(`^[a-z][a-z0-9_]{0,39}$`)
+	return re.MatchString(fl.Field().String())
+}
+
 func Validate(ctx context.Context, structure any) error {
-	return parseValidationErrors(Validator().StructCtx(ctx, structure))
+	return parseValidationErrors(Validator().StructCtx(ctx, structure), "")
 }
 
-func parseValidationErrors(err error) error {
+// ValidateVar - проверка отдельного значения по тегам, name заменяет путь к полю в тексте ошибки
+func ValidateVar(ctx context.Context, name string, value any, tag string) error {
+	return parseValidationErrors(Validator().VarCtx(ctx, value, tag), name)
+}
+
+// FieldError - ошибка в том же формате, что и у Validate, для проверок без тегов
+func FieldError(description, name string) error {
+	return errors.New(description + ": " + name)
+}
+
+func parseValidationErrors(err error, name string) error {
 	if err == nil {
 		return nil
 	}
@@ -63,7 +89,7 @@ func parseValidationErrors(err error) error {
 	validationError := vErrors[0]
 	var validationErrorDescription string
 	switch validationError.Tag() {
-	case "tag":
+	case "tag", "projectkey", "fieldkey":
 		validationErrorDescription = ErrInvalidFormat
 	case "required":
 		validationErrorDescription = ErrFieldRequired
@@ -75,9 +101,15 @@ func parseValidationErrors(err error) error {
 		validationErrorDescription = ErrFieldExceedsMaxVal
 	case "gt", "gte":
 		validationErrorDescription = ErrFieldBelowMinVal
+	case "oneof":
+		validationErrorDescription = ErrFieldNotAllowed
 	default:
 		validationErrorDescription = ErrUnknownValidation
 	}
 
-	return errors.New(validationErrorDescription + ": " + validationError.Namespace())
+	if name == "" {
+		name = validationError.Namespace()
+	}
+
+	return FieldError(validationErrorDescription, name)
 }